@@ -0,0 +1,86 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package aes
+
+import (
+	"crypto/aes"
+	"encoding/hex"
+	"strings"
+
+	"github.com/exohood/exohood-crypto-algorithms/algorithm"
+	"github.com/exohood/exohood-crypto-algorithms/cmac"
+)
+
+const (
+	checkValueDefaultBytes = 3
+	checkValueMinimumBytes = 2
+)
+
+var (
+	_ algorithm.AEAD                  = (*Cipher)(nil)
+	_ algorithm.KeyCheckValueProvider = (*Cipher)(nil)
+)
+
+func init() {
+	algorithm.RegisterAEAD(algorithm.AESGCM, aeadFactory(New))
+	algorithm.RegisterAEAD(algorithm.AESGCMSIV, aeadFactory(NewGCMSIV))
+	algorithm.RegisterAEAD(algorithm.AESGCMCommitting, aeadFactory(NewCommitting))
+	algorithm.RegisterAEAD(algorithm.XAES256GCM, aeadFactory(NewXAES))
+}
+
+func aeadFactory(constructor func([]byte, ...Option) (Cipher, error)) algorithm.AEADFactory {
+	return func(keyBytes []byte) (algorithm.AEAD, error) {
+		cipher, err := constructor(keyBytes)
+		if err != nil {
+			return nil, err
+		}
+		return &cipher, nil
+	}
+}
+
+// CheckValue returns the AES key check value, i.e. the leftmost 3 bytes of the AES-CMAC of a
+// zero block under the raw key bytes as per ANSI X9.24-1:2017
+func (cipher *Cipher) CheckValue() string {
+	checkValue := cipher.checkValueBytes()
+	if checkValue == nil {
+		return ""
+	}
+	return hex.EncodeToString(checkValue[:checkValueDefaultBytes])
+}
+
+// VerifyCheckValue compares the AES key check value with the given hex value, which must be at
+// least 2 bytes
+func (cipher *Cipher) VerifyCheckValue(checkValue string) bool {
+	checkValueBytes := len(checkValue) / 2
+	if checkValueBytes < checkValueMinimumBytes || checkValueBytes > aes.BlockSize {
+		return false
+	}
+
+	derived := cipher.checkValueBytes()
+	if derived == nil {
+		return false
+	}
+	return strings.EqualFold(hex.EncodeToString(derived[:checkValueBytes]), checkValue)
+}
+
+func (cipher *Cipher) checkValueBytes() []byte {
+	block, err := aes.NewCipher(cipher.KeyBytes)
+	if err != nil {
+		return nil
+	}
+	mac, err := cmac.Sum(block, make([]byte, aes.BlockSize))
+	if err != nil {
+		return nil
+	}
+	return mac
+}
@@ -0,0 +1,55 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package aes
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestAESCheckValue(t *testing.T) {
+	testData := map[string]string{
+		"2b7e151628aed2a6abf7158809cf4f3c":                                 "7AD386",
+		"000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f": "377822",
+	}
+
+	for key, expectedCheckValue := range testData {
+		keyBytes, _ := hex.DecodeString(key)
+		cipher, _ := New(keyBytes)
+
+		if !strings.EqualFold(cipher.CheckValue(), expectedCheckValue) {
+			t.Errorf("Expected check value %s but got %s", expectedCheckValue, cipher.CheckValue())
+		}
+		if !cipher.VerifyCheckValue(expectedCheckValue) {
+			t.Error("expect checkValue to be valid")
+		}
+	}
+}
+
+func TestAESCheckValueVerification(t *testing.T) {
+	keyBytes, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+	cipher, _ := NewGCMSIV(keyBytes)
+
+	if !cipher.VerifyCheckValue("7AD386C376") {
+		t.Error("expect checkValue to be valid")
+	}
+	if cipher.VerifyCheckValue("7AD387") {
+		t.Error("expect checkValue to be invalid")
+	}
+	if cipher.VerifyCheckValue("7A") {
+		t.Error("expect checkValue to be invalid if it is below the minimum required length")
+	}
+	if cipher.VerifyCheckValue("7AD386C3760FB3498361A1CB5563BD7000") {
+		t.Error("expect checkValue to be invalid if it is above the max allowed length")
+	}
+}
@@ -0,0 +1,174 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package aes
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"slices"
+)
+
+// NewCCM constructs a new AES-CCM (NIST SP 800-38C, RFC 3610) cipher using the raw key bytes
+// provided, the raw bytes must be either 16, 24, or 32 bytes. The tag size must be an even number
+// of bytes between 4 and 16, and the nonce size between 7 and 13 bytes. A longer nonce leaves
+// fewer bytes to encode the message length, i.e. a 13 bytes nonce limits messages to 64KiB.
+func NewCCM(keyBytes []byte, tagSize int, nonceSize int, opts ...Option) (Cipher, error) {
+	if tagSize < 4 || tagSize > 16 || tagSize%2 != 0 {
+		return Cipher{}, errors.New("CCM tag size must be an even number between 4 and 16 bytes")
+	}
+	if nonceSize < 7 || nonceSize > 13 {
+		return Cipher{}, errors.New("CCM nonce size must be between 7 and 13 bytes")
+	}
+
+	block, err := aes.NewCipher(keyBytes)
+	if err != nil {
+		return Cipher{}, err
+	}
+
+	return newCipher(&ccm{block, tagSize, nonceSize}, keyBytes, opts), nil
+}
+
+// ccm implements cipher.AEAD for AES-CCM
+type ccm struct {
+	block     cipher.Block
+	tagSize   int
+	nonceSize int
+}
+
+func (c *ccm) NonceSize() int {
+	return c.nonceSize
+}
+
+func (c *ccm) Overhead() int {
+	return c.tagSize
+}
+
+// maxLength is the largest message whose length fits in the 15 - nonce size length field
+func (c *ccm) maxLength() uint64 {
+	lengthSize := 15 - c.nonceSize
+	if lengthSize >= 8 {
+		return ^uint64(0)
+	}
+	return 1<<(8*lengthSize) - 1
+}
+
+func (c *ccm) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != c.nonceSize {
+		panic("aes: incorrect nonce length given to CCM")
+	}
+	if uint64(len(plaintext)) > c.maxLength() {
+		panic("aes: message too large for CCM")
+	}
+
+	var tag [aes.BlockSize]byte
+	c.cbcMAC(tag[:], nonce, plaintext, additionalData)
+
+	size := len(plaintext) + c.tagSize
+	ret := slices.Grow(dst, size)[:len(dst)+size]
+	out := ret[len(dst):]
+	c.ctr(nonce, tag[:], out[:len(plaintext)], plaintext)
+	copy(out[len(plaintext):], tag[:c.tagSize])
+	return ret
+}
+
+func (c *ccm) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != c.nonceSize {
+		panic("aes: incorrect nonce length given to CCM")
+	}
+	if len(ciphertext) < c.tagSize || uint64(len(ciphertext)-c.tagSize) > c.maxLength() {
+		return nil, errors.New("cipher: message authentication failed")
+	}
+
+	var tag [aes.BlockSize]byte
+	copy(tag[:], ciphertext[len(ciphertext)-c.tagSize:])
+	ciphertext = ciphertext[:len(ciphertext)-c.tagSize]
+
+	ret := slices.Grow(dst, len(ciphertext))[:len(dst)+len(ciphertext)]
+	out := ret[len(dst):]
+	c.ctr(nonce, tag[:], out, ciphertext)
+
+	var expectedTag [aes.BlockSize]byte
+	c.cbcMAC(expectedTag[:], nonce, out, additionalData)
+	if subtle.ConstantTimeCompare(expectedTag[:c.tagSize], tag[:c.tagSize]) != 1 {
+		for i := range out {
+			out[i] = 0
+		}
+		return nil, errors.New("cipher: message authentication failed")
+	}
+	return ret, nil
+}
+
+// counterBlock formats the counter block A_i of appendix A.3 of NIST SP 800-38C
+func (c *ccm) counterBlock(nonce []byte, i uint64) []byte {
+	block := make([]byte, aes.BlockSize)
+	block[0] = byte(14 - c.nonceSize)
+	copy(block[1:], nonce)
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], i)
+	lengthSize := 15 - c.nonceSize
+	copy(block[1+c.nonceSize:], counter[8-lengthSize:])
+	return block
+}
+
+// ctr encrypts the tag with the counter block 0 and the payload starting with the counter block 1
+func (c *ccm) ctr(nonce, tag, dst, src []byte) {
+	var s0 [aes.BlockSize]byte
+	c.block.Encrypt(s0[:], c.counterBlock(nonce, 0))
+	subtle.XORBytes(tag, tag, s0[:])
+
+	cipher.NewCTR(c.block, c.counterBlock(nonce, 1)).XORKeyStream(dst, src)
+}
+
+// cbcMAC computes the unencrypted tag over the formatted B_0 block, the encoded additional data
+// and the plaintext, as described in appendix A.2 of NIST SP 800-38C
+func (c *ccm) cbcMAC(tag, nonce, plaintext, additionalData []byte) {
+	b0 := c.counterBlock(nonce, uint64(len(plaintext)))
+	b0[0] = byte((c.tagSize-2)/2<<3 | (14 - c.nonceSize))
+	if len(additionalData) > 0 {
+		b0[0] |= 0x40
+	}
+	c.block.Encrypt(tag, b0)
+
+	if len(additionalData) > 0 {
+		var encodedLength []byte
+		switch length := uint64(len(additionalData)); {
+		case length < 1<<16-1<<8:
+			encodedLength = binary.BigEndian.AppendUint16(nil, uint16(length))
+		case length <= 1<<32-1:
+			encodedLength = binary.BigEndian.AppendUint32([]byte{0xff, 0xfe}, uint32(length))
+		default:
+			encodedLength = binary.BigEndian.AppendUint64([]byte{0xff, 0xff}, length)
+		}
+		c.macPadded(tag, append(encodedLength, additionalData...))
+	}
+	c.macPadded(tag, plaintext)
+}
+
+// macPadded chains the input zero-padded to a multiple of the block size into the CBC-MAC
+func (c *ccm) macPadded(tag, input []byte) {
+	var block [aes.BlockSize]byte
+	for len(input) > 0 {
+		n := copy(block[:], input)
+		for i := n; i < len(block); i++ {
+			block[i] = 0
+		}
+		input = input[n:]
+
+		subtle.XORBytes(tag, tag, block[:])
+		c.block.Encrypt(tag, tag)
+	}
+}
@@ -0,0 +1,133 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package aes
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// NIST SP 800-38C, appendix C
+func TestCCM_SP80038C(t *testing.T) {
+	testData := []struct {
+		tagSize    int
+		nonce      string
+		ad         string
+		plain      string
+		ciphertext string
+	}{
+		{4, "10111213141516", "0001020304050607", "20212223", "7162015b4dac255d"},
+		{6, "1011121314151617", "000102030405060708090a0b0c0d0e0f", "202122232425262728292a2b2c2d2e2f",
+			"d2a1f0e051ea5f62081a7792073d593d1fc64fbfaccd"},
+		{8, "101112131415161718191a1b", "000102030405060708090a0b0c0d0e0f10111213",
+			"202122232425262728292a2b2c2d2e2f3031323334353637",
+			"e3b201a9f5b71a7a9b1ceaeccd97e70b6176aad9a4428aa5484392fbc1b09951"},
+	}
+
+	keyBytes, _ := hex.DecodeString("404142434445464748494a4b4c4d4e4f")
+	for _, data := range testData {
+		nonce, _ := hex.DecodeString(data.nonce)
+		ad, _ := hex.DecodeString(data.ad)
+		plainBytes, _ := hex.DecodeString(data.plain)
+
+		cipher, err := NewCCM(keyBytes, data.tagSize, len(nonce))
+		if err != nil {
+			t.Fatalf("Did not expect an error but got %q", err)
+		}
+
+		cipherBytes := cipher.aead.Seal(nil, nonce, plainBytes, ad)
+		if hex.EncodeToString(cipherBytes) != data.ciphertext {
+			t.Errorf("Expected %s but got %x", data.ciphertext, cipherBytes)
+		}
+
+		decrypted, err := cipher.aead.Open(nil, nonce, cipherBytes, ad)
+		if err != nil || hex.EncodeToString(decrypted) != data.plain {
+			t.Errorf("Expected %s but got %x (%v)", data.plain, decrypted, err)
+		}
+
+		cipherBytes[0] ^= 0x01
+		if _, err := cipher.aead.Open(nil, nonce, cipherBytes, ad); err == nil {
+			t.Error("should be an error if the ciphertext is modified")
+		}
+	}
+}
+
+// NIST CAVP CCM, first vectors of DVPT, VADT, VNT and VPT for 128 and 256 bits keys
+func TestCCM_CAVP(t *testing.T) {
+	testData := []struct {
+		name       string
+		key        string
+		tagSize    int
+		nonce      string
+		ad         string
+		plain      string
+		ciphertext string
+	}{
+		{"DVPT128", "4ae701103c63deca5b5a3939d7d05992", 4, "5a8aa485c316e9", "", "", "02209f55"},
+		{"DVPT256", "eda32f751456e33195f1f499cf2dc7c97ea127b6d488f211ccc5126fbb24afa6", 4, "a544218dadd3c1", "", "", "469c90bb"},
+		{"VADT128", "d24a3d3dde8c84830280cb87abad0bb3", 16, "f1100035bb24a8d26004e0e24b", "",
+			"7c86135ed9c2a515aaae0e9a208133897269220f30870006",
+			"1faeb0ee2ca2cd52f0aa3966578344f24e69b742c4ab37ab1123301219c70599b7c373ad4b3ad67b"},
+		{"VADT256", "26511fb51fcfa75cb4b44da75a6e5a0eb8d9c8f3b906f886df3ba3e6da3a1389", 16, "72a60f345a1978fb40f28a2fa4", "",
+			"30d56ff2a25b83fee791110fcaea48e41db7c7f098a81000",
+			"55f068c0bbba8b598013dd1841fd740fda2902322148ab5e935753e601b79db4ae730b6ae3500731"},
+		{"VNT128", "c0425ed20cd28fda67a2bcc0ab342a49", 16, "37667f334dce90",
+			"0b3e8d9785c74c8f41ea257d4d87495ffbbb335542b12e0d62bb177ec7a164d9",
+			"4f065a23eeca6b18d118e1de4d7e5ca1a7c0e556d786d407",
+			"768fccdf4898bca099e33c3d40565497dec22dd6e33dcf4384d71be8565c21a455db45816da8158c"},
+		{"VPT128", "2ebf60f0969013a54a3dedb19d20f6c8", 16, "1de8c5e21f9db33123ff870add",
+			"e1de6c6119d7db471136285d10b47a450221b16978569190ef6a22b055295603", "",
+			"0ead29ef205fbb86d11abe5ed704b880"},
+	}
+
+	for _, data := range testData {
+		keyBytes, _ := hex.DecodeString(data.key)
+		nonce, _ := hex.DecodeString(data.nonce)
+		ad, _ := hex.DecodeString(data.ad)
+		plainBytes, _ := hex.DecodeString(data.plain)
+
+		cipher, err := NewCCM(keyBytes, data.tagSize, len(nonce))
+		if err != nil {
+			t.Fatalf("%s: did not expect an error but got %q", data.name, err)
+		}
+
+		cipherBytes := cipher.aead.Seal(nil, nonce, plainBytes, ad)
+		if hex.EncodeToString(cipherBytes) != data.ciphertext {
+			t.Errorf("%s: expected %s but got %x", data.name, data.ciphertext, cipherBytes)
+		}
+
+		decrypted, err := cipher.aead.Open(nil, nonce, cipherBytes, ad)
+		if err != nil || hex.EncodeToString(decrypted) != data.plain {
+			t.Errorf("%s: expected %s but got %x (%v)", data.name, data.plain, decrypted, err)
+		}
+	}
+}
+
+func TestCCM_EncryptDecrypt(t *testing.T) {
+	keyBytes, _ := hex.DecodeString("404142434445464748494a4b4c4d4e4f")
+	cipher, _ := NewCCM(keyBytes, 16, 13)
+
+	cipherBytes, nonce, _ := cipher.Encrypt([]byte("terminal payload"), true)
+	if len(nonce) != 13 || len(cipherBytes) != 13+16+16 {
+		t.Errorf("Unexpected nonce length %d or cipher bytes length %d", len(nonce), len(cipherBytes))
+	}
+	plainBytes, err := cipher.Decrypt(cipherBytes, nil)
+	if err != nil || string(plainBytes) != "terminal payload" {
+		t.Errorf("Expected terminal payload but got %s (%v)", plainBytes, err)
+	}
+
+	for _, sizes := range [][2]int{{3, 12}, {5, 12}, {18, 12}, {16, 6}, {16, 14}} {
+		if _, err := NewCCM(keyBytes, sizes[0], sizes[1]); err == nil {
+			t.Errorf("should be an error for tag size %d and nonce size %d", sizes[0], sizes[1])
+		}
+	}
+}
@@ -10,26 +10,52 @@
 	limitations under the License.
 */
 
-// Package aes provides wrapper methods on top of the AES GCM cipher for our own usage
+// Package aes provides wrapper methods on top of the AES GCM cipher, and the other AES based modes, for
+// our own usage
 package aes
 
 import (
 	"crypto/aes"
 	"crypto/cipher"
 	"errors"
+	"fmt"
+	"slices"
 
-	"github.com/hashicorp/go-uuid"
+	"github.com/exohood/exohood-crypto-algorithms/usage"
 )
 
-// Cipher is wrapper of the AES GCM cipher and stores the raw key bytes
+// Cipher is wrapper of the AES GCM cipher, or of one of the other AES based AEAD modes, and stores
+// the raw key bytes
 type Cipher struct {
-	gcm      cipher.AEAD
+	aead     cipher.AEAD
+	nonces   NonceSource
+	usage    *usage.Tracker
 	KeyBytes []byte
 }
 
+// Option configures optional behaviors of the Cipher
+type Option func(*Cipher)
+
+// WithUsageTracker accounts every encryption against the tracker, Encrypt refuses to run once
+// the tracker hard limit has been exceeded
+func WithUsageTracker(tracker *usage.Tracker) Option {
+	return func(cipher *Cipher) {
+		cipher.usage = tracker
+	}
+}
+
+// newCipher wraps the AEAD and applies the options
+func newCipher(aead cipher.AEAD, keyBytes []byte, opts []Option) Cipher {
+	c := Cipher{aead: aead, nonces: randomNonces{}, KeyBytes: keyBytes}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
 // New constructs a new AES GCM cipher using the raw key bytes provided, the raw bytes must be
 // either 16, 24, or 32 bytes
-func New(keyBytes []byte) (Cipher, error) {
+func New(keyBytes []byte, opts ...Option) (Cipher, error) {
 	var err error
 
 	// Setup the cipher
@@ -44,18 +70,18 @@ func New(keyBytes []byte) (Cipher, error) {
 		return Cipher{}, err
 	}
 
-	return Cipher{gcmCipher, keyBytes}, nil
+	return newCipher(gcmCipher, keyBytes, opts), nil
 }
 
 // Encrypt takes plain bytes and output cipher bytes, the nonce will be prefixed to
-// cipher bytes if prefixNonce is true.
+// cipher bytes if prefixNonce is true. Nonces are random unless another source is configured.
 func (cipher *Cipher) Encrypt(plainBytes []byte, prefixNonce bool) ([]byte, []byte, error) {
-	nonce, err := uuid.GenerateRandomBytes(cipher.gcm.NonceSize())
-	if err != nil {
-		return nil, nil, errors.New("fail to generate nonce")
+	nonce := make([]byte, cipher.aead.NonceSize())
+	if err := cipher.nextNonce(nonce); err != nil {
+		return nil, nil, err
 	}
 
-	cipherBytes := cipher.gcm.Seal(nil, nonce, plainBytes, nil)
+	cipherBytes := cipher.aead.Seal(nil, nonce, plainBytes, nil)
 	if prefixNonce {
 		cipherBytes = append(nonce, cipherBytes...)
 	}
@@ -67,9 +93,83 @@ func (cipher *Cipher) Encrypt(plainBytes []byte, prefixNonce bool) ([]byte, []by
 // to cipher bytes if its value is not being provided
 func (cipher *Cipher) Decrypt(cipherBytes []byte, nonce []byte) ([]byte, error) {
 	if nonce == nil {
-		nonceSize := cipher.gcm.NonceSize()
+		nonceSize := cipher.aead.NonceSize()
+		if len(cipherBytes) < nonceSize {
+			return nil, errors.New("ciphertext is too short")
+		}
 		nonce, cipherBytes = cipherBytes[:nonceSize], cipherBytes[nonceSize:]
 	}
 
-	return cipher.gcm.Open(nil, nonce, cipherBytes, nil)
+	return cipher.aead.Open(nil, nonce, cipherBytes, nil)
+}
+
+// NonceSize returns the size of the nonce prefixed by Seal
+func (cipher *Cipher) NonceSize() int {
+	return cipher.aead.NonceSize()
+}
+
+// Overhead returns the maximum difference between the lengths of the sealed and the plain bytes,
+// excluding the nonce
+func (cipher *Cipher) Overhead() int {
+	return cipher.aead.Overhead()
+}
+
+// Seal encrypts the plain bytes like Encrypt with a prefixed nonce, and appends the nonce and the
+// cipher bytes to dst. It does not allocate when dst has enough capacity, i.e. NonceSize() +
+// len(plainBytes) + Overhead(). To encrypt in place, store the plain bytes at buf[NonceSize():]
+// and pass buf[:0] as dst.
+func (cipher *Cipher) Seal(dst []byte, plainBytes []byte) ([]byte, error) {
+	nonceSize := cipher.aead.NonceSize()
+	ret := slices.Grow(dst, nonceSize)[:len(dst)+nonceSize]
+	nonce := ret[len(dst):]
+	if err := cipher.nextNonce(nonce); err != nil {
+		return nil, err
+	}
+	return cipher.aead.Seal(ret, nonce, plainBytes, nil), nil
+}
+
+// Open decrypts the output of Seal, or of Encrypt with a prefixed nonce, and appends the plain
+// bytes to dst. To decrypt in place, pass sealed[NonceSize():NonceSize()] as dst.
+func (cipher *Cipher) Open(dst []byte, sealed []byte) ([]byte, error) {
+	nonceSize := cipher.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return nil, errors.New("cipher: message authentication failed")
+	}
+	return cipher.aead.Open(dst, sealed[:nonceSize], sealed[nonceSize:], nil)
+}
+
+// SealWithNonce encrypts the plain bytes like Encrypt without a prefixed nonce, it writes the next
+// nonce into the nonce slice, which must be NonceSize() bytes, and appends the cipher bytes to dst.
+// To encrypt in place, pass plainBytes[:0] as dst.
+func (cipher *Cipher) SealWithNonce(dst []byte, nonce []byte, plainBytes []byte) ([]byte, error) {
+	if len(nonce) != cipher.aead.NonceSize() {
+		return nil, fmt.Errorf("nonce must be %d bytes", cipher.aead.NonceSize())
+	}
+	if err := cipher.nextNonce(nonce); err != nil {
+		return nil, err
+	}
+	return cipher.aead.Seal(dst, nonce, plainBytes, nil), nil
+}
+
+// OpenWithNonce decrypts the output of SealWithNonce and appends the plain bytes to dst. To decrypt
+// in place, pass cipherBytes[:0] as dst.
+func (cipher *Cipher) OpenWithNonce(dst []byte, nonce []byte, cipherBytes []byte) ([]byte, error) {
+	if len(nonce) != cipher.aead.NonceSize() {
+		return nil, fmt.Errorf("nonce must be %d bytes", cipher.aead.NonceSize())
+	}
+	return cipher.aead.Open(dst, nonce, cipherBytes, nil)
+}
+
+// nextNonce accounts the encryption against the usage tracker and fills the next nonce
+func (cipher *Cipher) nextNonce(nonce []byte) error {
+	if cipher.usage != nil {
+		if err := cipher.usage.Use(1); err != nil {
+			return err
+		}
+	}
+
+	if err := cipher.nonces.FillNonce(nonce); err != nil {
+		return fmt.Errorf("fail to generate nonce: %w", err)
+	}
+	return nil
 }
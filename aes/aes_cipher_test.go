@@ -12,9 +12,12 @@
 package aes
 
 import (
+	"bytes"
 	"encoding/hex"
+	"errors"
 	"testing"
 
+	"github.com/exohood/exohood-crypto-algorithms/usage"
 	"github.com/hashicorp/go-uuid"
 )
 
@@ -81,4 +84,140 @@ func TestAESCipher_EncryptAndDecryptPrefixNonce(t *testing.T) {
 			t.Errorf("Expected %s but get %s", testData, string(plainBytes))
 		}
 	}
+
+	if _, err := cipher.Decrypt(make([]byte, 10), nil); err == nil {
+		t.Error("should be an error if the ciphertext is too short")
+	}
+}
+
+func TestAESCipher_UsageLimits(t *testing.T) {
+	keyBytes, _ := uuid.GenerateRandomBytes(32)
+
+	rotations := 0
+	tracker := usage.NewTracker("aes-key", usage.NewMemoryStore(), usage.Limits{
+		SoftLimit:   2,
+		HardLimit:   3,
+		OnSoftLimit: func(string, uint64) { rotations++ },
+	})
+	cipher, _ := New(keyBytes, WithUsageTracker(tracker))
+
+	for i := 0; i < 3; i++ {
+		if _, _, err := cipher.Encrypt([]byte("my secret 1234"), true); err != nil {
+			t.Errorf("Did not expect an encryption error but got %q", err)
+		}
+	}
+	if rotations != 1 {
+		t.Errorf("Expected the soft limit to be reported once but got %d", rotations)
+	}
+
+	if _, _, err := cipher.Encrypt([]byte("my secret 1234"), true); !errors.Is(err, usage.ErrLimitExceeded) {
+		t.Errorf("Expected a limit exceeded error but got %v", err)
+	}
+}
+
+func TestAESCipher_SealAndOpen(t *testing.T) {
+	keyBytes, _ := uuid.GenerateRandomBytes(32)
+	macKeyBytes, _ := uuid.GenerateRandomBytes(32)
+
+	gcm, _ := New(keyBytes)
+	gcmSIV, _ := NewGCMSIV(keyBytes)
+	xaes, _ := NewXAES(keyBytes)
+	committing, _ := NewCommitting(keyBytes)
+	ccm, _ := NewCCM(keyBytes, 16, 12)
+	etm, _ := NewEncryptThenMAC(keyBytes, macKeyBytes, CTR, NoPadding)
+
+	plainBytes := []byte("4111111111111111")
+	for i, cipher := range []Cipher{gcm, gcmSIV, xaes, committing, ccm, etm} {
+		sealed, err := cipher.Seal([]byte("header"), plainBytes)
+		if err != nil {
+			t.Fatalf("#%d: did not expect an error but got %q", i, err)
+		}
+		if string(sealed[:6]) != "header" {
+			t.Errorf("#%d: expected the sealed bytes to be appended to dst", i)
+		}
+
+		decrypted, err := cipher.Decrypt(sealed[6:], nil)
+		if err != nil || !bytes.Equal(decrypted, plainBytes) {
+			t.Errorf("#%d: expected %s but got %s (%v)", i, plainBytes, decrypted, err)
+		}
+
+		// In place encryption and decryption within a single buffer
+		buf := make([]byte, cipher.NonceSize(), cipher.NonceSize()+len(plainBytes)+cipher.Overhead())
+		buf = append(buf, plainBytes...)
+		sealed, _ = cipher.Seal(buf[:0], buf[cipher.NonceSize():])
+		if &sealed[0] != &buf[0] {
+			t.Errorf("#%d: expected the sealing to happen in place", i)
+		}
+		opened, err := cipher.Open(sealed[cipher.NonceSize():cipher.NonceSize()], sealed)
+		if err != nil || !bytes.Equal(opened, plainBytes) {
+			t.Errorf("#%d: expected %s but got %s (%v)", i, plainBytes, opened, err)
+		}
+
+		nonce := make([]byte, cipher.NonceSize())
+		detached, _ := cipher.SealWithNonce(nil, nonce, plainBytes)
+		opened, err = cipher.OpenWithNonce(detached[:0], nonce, detached)
+		if err != nil || !bytes.Equal(opened, plainBytes) {
+			t.Errorf("#%d: expected %s but got %s (%v)", i, plainBytes, opened, err)
+		}
+
+		if _, err := cipher.Open(nil, sealed[:cipher.NonceSize()-1]); err == nil {
+			t.Errorf("#%d: should be an error if the sealed bytes are shorter than the nonce", i)
+		}
+	}
+}
+
+func TestAESCipher_SealAndOpenAllocations(t *testing.T) {
+	keyBytes, _ := uuid.GenerateRandomBytes(32)
+	cipher, _ := New(keyBytes)
+
+	plainBytes := make([]byte, 32)
+	sealed := make([]byte, 0, cipher.NonceSize()+len(plainBytes)+cipher.Overhead())
+	opened := make([]byte, 0, len(plainBytes))
+
+	allocs := testing.AllocsPerRun(100, func() {
+		sealed, _ = cipher.Seal(sealed[:0], plainBytes)
+		opened, _ = cipher.Open(opened[:0], sealed)
+	})
+	if allocs != 0 {
+		t.Errorf("Expected no allocation but got %v per run", allocs)
+	}
+}
+
+func BenchmarkAESCipher_Encrypt(b *testing.B) {
+	keyBytes, _ := uuid.GenerateRandomBytes(32)
+	cipher, _ := New(keyBytes)
+	plainBytes := make([]byte, 32)
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(plainBytes)))
+	for i := 0; i < b.N; i++ {
+		cipher.Encrypt(plainBytes, true)
+	}
+}
+
+func BenchmarkAESCipher_Seal(b *testing.B) {
+	keyBytes, _ := uuid.GenerateRandomBytes(32)
+	cipher, _ := New(keyBytes)
+	plainBytes := make([]byte, 32)
+	sealed := make([]byte, 0, cipher.NonceSize()+len(plainBytes)+cipher.Overhead())
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(plainBytes)))
+	for i := 0; i < b.N; i++ {
+		sealed, _ = cipher.Seal(sealed[:0], plainBytes)
+	}
+}
+
+func BenchmarkAESCipher_Open(b *testing.B) {
+	keyBytes, _ := uuid.GenerateRandomBytes(32)
+	cipher, _ := New(keyBytes)
+	plainBytes := make([]byte, 32)
+	sealed, _ := cipher.Seal(nil, plainBytes)
+	opened := make([]byte, 0, len(plainBytes))
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(plainBytes)))
+	for i := 0; i < b.N; i++ {
+		opened, _ = cipher.Open(opened[:0], sealed)
+	}
 }
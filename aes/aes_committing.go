@@ -0,0 +1,110 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package aes
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"slices"
+)
+
+const commitmentSize = sha256.Size
+
+var (
+	// ErrKeyCommitment is returned when the ciphertext was not produced under the key, it is checked
+	// before any decryption is attempted
+	ErrKeyCommitment = errors.New("key commitment does not match")
+
+	committingEncryptionLabel = []byte("exohood aes committing encryption key")
+	committingCommitmentLabel = []byte("exohood aes committing commitment key")
+)
+
+// NewCommitting constructs a new key-committing AES GCM cipher using the raw key bytes provided,
+// the raw bytes must be either 16, 24, or 32 bytes. The encryption key and the commitment key are
+// derived from the key with HMAC-SHA256, and an HMAC-SHA256 commitment of the nonce is prepended
+// to the GCM output, so a ciphertext can only be opened under the key that produced it.
+func NewCommitting(keyBytes []byte, opts ...Option) (Cipher, error) {
+	if len(keyBytes) != 16 && len(keyBytes) != 24 && len(keyBytes) != 32 {
+		return Cipher{}, errors.New("AES key must be either 16, 24 or 32 bytes")
+	}
+
+	block, err := aes.NewCipher(hmacSHA256(keyBytes, committingEncryptionLabel))
+	if err != nil {
+		return Cipher{}, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return Cipher{}, err
+	}
+
+	aead := &committing{gcm, hmacSHA256(keyBytes, committingCommitmentLabel)}
+	return newCipher(aead, keyBytes, opts), nil
+}
+
+// committing implements cipher.AEAD as commitment || GCM output
+type committing struct {
+	gcm           cipher.AEAD
+	commitmentKey []byte
+}
+
+func (c *committing) NonceSize() int {
+	return c.gcm.NonceSize()
+}
+
+func (c *committing) Overhead() int {
+	return commitmentSize + c.gcm.Overhead()
+}
+
+// Seal moves the plaintext after the commitment before sealing it in place, so that plaintext[:0]
+// can be used as dst like with any other AEAD
+func (c *committing) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	size := commitmentSize + len(plaintext) + c.gcm.Overhead()
+	ret := slices.Grow(dst, size)[:len(dst)+size]
+	out := ret[len(dst):]
+	sealed := out[commitmentSize:]
+	copy(sealed, plaintext)
+	c.gcm.Seal(sealed[:0], nonce, sealed[:len(plaintext)], additionalData)
+	copy(out, hmacSHA256(c.commitmentKey, nonce))
+	return ret
+}
+
+func (c *committing) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < c.Overhead() {
+		return nil, errors.New("cipher: message authentication failed")
+	}
+
+	commitment := hmacSHA256(c.commitmentKey, nonce)
+	if subtle.ConstantTimeCompare(commitment, ciphertext[:commitmentSize]) != 1 {
+		return nil, ErrKeyCommitment
+	}
+
+	// The GCM output is moved over the commitment and opened in place, for the same reason as Seal
+	size := len(ciphertext) - commitmentSize
+	ret := slices.Grow(dst, size)[:len(dst)+size]
+	out := ret[len(dst):]
+	copy(out, ciphertext[commitmentSize:])
+	if _, err := c.gcm.Open(out[:0], nonce, out, additionalData); err != nil {
+		return nil, err
+	}
+	return ret[:len(ret)-c.gcm.Overhead()], nil
+}
+
+func hmacSHA256(key []byte, message []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(message)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,73 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package aes
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/go-uuid"
+)
+
+func TestCommitting_EncryptAndDecrypt(t *testing.T) {
+	keyBytes, _ := uuid.GenerateRandomBytes(32)
+	cipher, _ := NewCommitting(keyBytes)
+
+	testDatas := []string{
+		"my secret 1234",
+		"",
+	}
+
+	for _, testData := range testDatas {
+		cipherBytes, _, err := cipher.Encrypt([]byte(testData), true)
+		if err != nil {
+			t.Errorf("Did not expect an encryption error but got %q", err)
+		}
+		if len(cipherBytes) != 12+32+len(testData)+16 {
+			t.Errorf("Expected the nonce, commitment and tag overhead but got %d bytes", len(cipherBytes))
+		}
+
+		plainBytes, err := cipher.Decrypt(cipherBytes, nil)
+		if err != nil {
+			t.Errorf("Did not expect a decryption error but got %q", err)
+		}
+		if testData != string(plainBytes) {
+			t.Errorf("Expected %s but get %s", testData, string(plainBytes))
+		}
+	}
+}
+
+func TestCommitting_RejectsOtherKeys(t *testing.T) {
+	keyBytes, _ := uuid.GenerateRandomBytes(32)
+	rotatedKeyBytes, _ := uuid.GenerateRandomBytes(32)
+	cipher, _ := NewCommitting(keyBytes)
+	rotatedCipher, _ := NewCommitting(rotatedKeyBytes)
+
+	cipherBytes, nonce, _ := cipher.Encrypt([]byte("my secret 1234"), false)
+	if _, err := rotatedCipher.Decrypt(cipherBytes, nonce); !errors.Is(err, ErrKeyCommitment) {
+		t.Errorf("Expected a key commitment error but got %v", err)
+	}
+
+	// The commitment is bound to the nonce
+	otherNonce := append([]byte{nonce[0] ^ 1}, nonce[1:]...)
+	if _, err := cipher.Decrypt(cipherBytes, otherNonce); !errors.Is(err, ErrKeyCommitment) {
+		t.Errorf("Expected a key commitment error but got %v", err)
+	}
+
+	cipherBytes[len(cipherBytes)-1] ^= 1
+	if _, err := cipher.Decrypt(cipherBytes, nonce); err == nil || errors.Is(err, ErrKeyCommitment) {
+		t.Errorf("Expected an authentication error but got %v", err)
+	}
+	if _, err := cipher.Decrypt(cipherBytes[:40], nonce); err == nil {
+		t.Error("should be an error if the ciphertext is too short")
+	}
+}
@@ -0,0 +1,241 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package aes
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"slices"
+)
+
+const (
+	gcmSIVNonceSize = 12
+	gcmSIVTagSize   = 16
+	// RFC 8452 limits both the plaintext and the additional data to 2^36 bytes
+	gcmSIVMaxInput = 1 << 36
+)
+
+// NewGCMSIV constructs a new AES-GCM-SIV (RFC 8452) cipher using the raw key bytes provided, the
+// raw bytes must be either 16 or 32 bytes. Unlike AES-GCM, encrypting twice under the same nonce
+// only reveals whether the two plaintexts were equal.
+func NewGCMSIV(keyBytes []byte, opts ...Option) (Cipher, error) {
+	if len(keyBytes) != 16 && len(keyBytes) != 32 {
+		return Cipher{}, errors.New("AES-GCM-SIV key must be either 16 or 32 bytes")
+	}
+
+	keyGenerator, err := aes.NewCipher(keyBytes)
+	if err != nil {
+		return Cipher{}, err
+	}
+
+	return newCipher(&gcmSIV{keyGenerator, len(keyBytes)}, keyBytes, opts), nil
+}
+
+// gcmSIV implements cipher.AEAD for AES-GCM-SIV, the per-nonce keys are derived from the key
+// generating key on every call
+type gcmSIV struct {
+	keyGenerator cipher.Block
+	keySize      int
+}
+
+func (g *gcmSIV) NonceSize() int {
+	return gcmSIVNonceSize
+}
+
+func (g *gcmSIV) Overhead() int {
+	return gcmSIVTagSize
+}
+
+func (g *gcmSIV) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != gcmSIVNonceSize {
+		panic("aes: incorrect nonce length given to GCM-SIV")
+	}
+	if uint64(len(plaintext)) > gcmSIVMaxInput || uint64(len(additionalData)) > gcmSIVMaxInput {
+		panic("aes: message too large for GCM-SIV")
+	}
+
+	authKey, encBlock := g.deriveKeys(nonce)
+
+	var tag [gcmSIVTagSize]byte
+	g.computeTag(tag[:], authKey, encBlock, nonce, plaintext, additionalData)
+
+	size := len(plaintext) + gcmSIVTagSize
+	ret := slices.Grow(dst, size)[:len(dst)+size]
+	out := ret[len(dst):]
+	gcmSIVCTR(encBlock, tag[:], out[:len(plaintext)], plaintext)
+	copy(out[len(plaintext):], tag[:])
+	return ret
+}
+
+func (g *gcmSIV) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != gcmSIVNonceSize {
+		panic("aes: incorrect nonce length given to GCM-SIV")
+	}
+	if len(ciphertext) < gcmSIVTagSize || uint64(len(ciphertext)) > gcmSIVMaxInput+gcmSIVTagSize ||
+		uint64(len(additionalData)) > gcmSIVMaxInput {
+		return nil, errors.New("cipher: message authentication failed")
+	}
+
+	tag := ciphertext[len(ciphertext)-gcmSIVTagSize:]
+	ciphertext = ciphertext[:len(ciphertext)-gcmSIVTagSize]
+
+	authKey, encBlock := g.deriveKeys(nonce)
+
+	ret := slices.Grow(dst, len(ciphertext))[:len(dst)+len(ciphertext)]
+	out := ret[len(dst):]
+	gcmSIVCTR(encBlock, tag, out, ciphertext)
+
+	var expectedTag [gcmSIVTagSize]byte
+	g.computeTag(expectedTag[:], authKey, encBlock, nonce, out, additionalData)
+	if subtle.ConstantTimeCompare(expectedTag[:], tag) != 1 {
+		for i := range out {
+			out[i] = 0
+		}
+		return nil, errors.New("cipher: message authentication failed")
+	}
+	return ret, nil
+}
+
+// deriveKeys derives the message authentication key and the message encryption key for the nonce
+// as described in section 4 of RFC 8452
+func (g *gcmSIV) deriveKeys(nonce []byte) ([]byte, cipher.Block) {
+	var input, output [aes.BlockSize]byte
+	copy(input[4:], nonce)
+
+	derived := make([]byte, 16+g.keySize)
+	for i := 0; i < len(derived)/8; i++ {
+		binary.LittleEndian.PutUint32(input[:4], uint32(i))
+		g.keyGenerator.Encrypt(output[:], input[:])
+		copy(derived[i*8:], output[:8])
+	}
+
+	encBlock, err := aes.NewCipher(derived[16:])
+	if err != nil {
+		panic("aes: failed to derive GCM-SIV encryption key")
+	}
+	return derived[:16], encBlock
+}
+
+// computeTag runs POLYVAL over the additional data, the plaintext and their bit lengths and
+// encrypts the result with the message encryption key
+func (g *gcmSIV) computeTag(tag, authKey []byte, encBlock cipher.Block, nonce, plaintext, additionalData []byte) {
+	p := newPolyval(authKey)
+	p.updatePadded(additionalData)
+	p.updatePadded(plaintext)
+
+	var lengthBlock [16]byte
+	binary.LittleEndian.PutUint64(lengthBlock[:8], uint64(len(additionalData))*8)
+	binary.LittleEndian.PutUint64(lengthBlock[8:], uint64(len(plaintext))*8)
+	p.updatePadded(lengthBlock[:])
+
+	var s [16]byte
+	p.sum(s[:])
+	for i := range nonce {
+		s[i] ^= nonce[i]
+	}
+	s[15] &= 0x7f
+	encBlock.Encrypt(tag, s[:])
+}
+
+// gcmSIVCTR is the CTR variant of RFC 8452 where the initial counter block is the tag with the
+// most significant bit set, and only the first 32 bits are incremented as a little endian integer
+func gcmSIVCTR(block cipher.Block, tag, dst, src []byte) {
+	var counterBlock, keyStream [aes.BlockSize]byte
+	copy(counterBlock[:], tag)
+	counterBlock[15] |= 0x80
+
+	for len(src) > 0 {
+		block.Encrypt(keyStream[:], counterBlock[:])
+		binary.LittleEndian.PutUint32(counterBlock[:4], binary.LittleEndian.Uint32(counterBlock[:4])+1)
+
+		n := subtle.XORBytes(dst, src, keyStream[:])
+		dst, src = dst[n:], src[n:]
+	}
+}
+
+// polyval computes POLYVAL via its GHASH equivalent described in appendix A of RFC 8452, i.e.
+// POLYVAL(H, X_1, ..., X_n) = ByteReverse(GHASH(mulX_GHASH(ByteReverse(H)), ByteReverse(X_1), ...))
+type polyval struct {
+	h, y gcmFieldElement
+}
+
+// gcmFieldElement is an element of GF(2^128) in the GCM bit order, high holds the first 8 bytes
+type gcmFieldElement struct {
+	high, low uint64
+}
+
+func newPolyval(key []byte) *polyval {
+	h := reversedFieldElement(key)
+	return &polyval{h: h.mulX()}
+}
+
+// updatePadded absorbs the input zero-padded to a multiple of the block size
+func (p *polyval) updatePadded(input []byte) {
+	var block [16]byte
+	for len(input) > 0 {
+		n := copy(block[:], input)
+		for i := n; i < len(block); i++ {
+			block[i] = 0
+		}
+		input = input[n:]
+
+		x := reversedFieldElement(block[:])
+		p.y.high ^= x.high
+		p.y.low ^= x.low
+		p.y = p.y.mul(p.h)
+	}
+}
+
+func (p *polyval) sum(out []byte) {
+	binary.LittleEndian.PutUint64(out[:8], p.y.low)
+	binary.LittleEndian.PutUint64(out[8:], p.y.high)
+}
+
+// reversedFieldElement loads the byte reversal of the 16 byte block
+func reversedFieldElement(block []byte) gcmFieldElement {
+	return gcmFieldElement{
+		high: binary.LittleEndian.Uint64(block[8:16]),
+		low:  binary.LittleEndian.Uint64(block[:8]),
+	}
+}
+
+// mulX multiplies the element by x, reducing by the GCM polynomial
+func (x gcmFieldElement) mulX() gcmFieldElement {
+	mask := -(x.low & 1)
+	return gcmFieldElement{
+		high: (x.high >> 1) ^ (0xe1 << 56 & mask),
+		low:  (x.low >> 1) | (x.high << 63),
+	}
+}
+
+// mul is the constant time bitwise multiplication from section 6.3 of NIST SP 800-38D
+func (x gcmFieldElement) mul(y gcmFieldElement) gcmFieldElement {
+	var z gcmFieldElement
+	v := y
+	for i := 0; i < 128; i++ {
+		var bit uint64
+		if i < 64 {
+			bit = (x.high >> (63 - i)) & 1
+		} else {
+			bit = (x.low >> (127 - i)) & 1
+		}
+		mask := -bit
+		z.high ^= v.high & mask
+		z.low ^= v.low & mask
+		v = v.mulX()
+	}
+	return z
+}
@@ -0,0 +1,146 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package aes
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-uuid"
+)
+
+func TestPolyval(t *testing.T) {
+	// RFC 8452 appendix A
+	h, _ := hex.DecodeString("25629347589242761d31f826ba4b757b")
+	x, _ := hex.DecodeString("4f4f95668c83dfb6401762bb2d01a262d1a24ddd2721d006bbe45f20d3c9f362")
+
+	p := newPolyval(h)
+	p.updatePadded(x)
+	sum := make([]byte, 16)
+	p.sum(sum)
+
+	if hex.EncodeToString(sum) != "f7a3b47b846119fae5b7866cf5e5b77e" {
+		t.Errorf("Expected POLYVAL f7a3b47b846119fae5b7866cf5e5b77e but got %x", sum)
+	}
+}
+
+func TestGCMSIV_RFC8452Vectors(t *testing.T) {
+	// RFC 8452 appendix C.1 and C.2
+	testDatas := []struct {
+		key, nonce, plaintext, aad, result string
+	}{
+		{
+			"01000000000000000000000000000000", "030000000000000000000000",
+			"", "",
+			"dc20e2d83f25705bb49e439eca56de25",
+		},
+		{
+			"01000000000000000000000000000000", "030000000000000000000000",
+			"0100000000000000", "",
+			"b5d839330ac7b786578782fff6013b815b287c22493a364c",
+		},
+		{
+			"01000000000000000000000000000000", "030000000000000000000000",
+			"010000000000000000000000", "",
+			"7323ea61d05932260047d942a4978db357391a0bc4fdec8b0d106639",
+		},
+		{
+			"01000000000000000000000000000000", "030000000000000000000000",
+			"01000000000000000000000000000000", "",
+			"743f7c8077ab25f8624e2e948579cf77303aaf90f6fe21199c6068577437a0c4",
+		},
+		{
+			"0100000000000000000000000000000000000000000000000000000000000000", "030000000000000000000000",
+			"", "",
+			"07f5f4169bbf55a8400cd47ea6fd400f",
+		},
+		{
+			"0100000000000000000000000000000000000000000000000000000000000000", "030000000000000000000000",
+			"0100000000000000", "",
+			"c2ef328e5c71c83b843122130f7364b761e0b97427e3df28",
+		},
+	}
+
+	for _, testData := range testDatas {
+		key, _ := hex.DecodeString(testData.key)
+		nonce, _ := hex.DecodeString(testData.nonce)
+		plaintext, _ := hex.DecodeString(testData.plaintext)
+		aad, _ := hex.DecodeString(testData.aad)
+
+		cipher, err := NewGCMSIV(key)
+		if err != nil {
+			t.Fatalf("Did not expect an error but got %q", err)
+		}
+
+		result := cipher.aead.Seal(nil, nonce, plaintext, aad)
+		if !strings.EqualFold(hex.EncodeToString(result), testData.result) {
+			t.Errorf("Expected %s but got %x", testData.result, result)
+		}
+
+		opened, err := cipher.aead.Open(nil, nonce, result, aad)
+		if err != nil {
+			t.Errorf("Did not expect a decryption error but got %q", err)
+		}
+		if !bytes.Equal(opened, plaintext) {
+			t.Errorf("Expected %x but got %x", plaintext, opened)
+		}
+	}
+}
+
+func TestGCMSIV_EncryptAndDecrypt(t *testing.T) {
+	keyBytes, _ := uuid.GenerateRandomBytes(32)
+	cipher, _ := NewGCMSIV(keyBytes)
+
+	testDatas := []string{
+		"my secret 1234",
+		"123456789",
+		"a longer message that spans over more than two AES blocks",
+	}
+
+	for _, testData := range testDatas {
+		cipherBytes, _, err := cipher.Encrypt([]byte(testData), true)
+		if err != nil {
+			t.Errorf("Did not expect an encryption error but got %q", err)
+		}
+
+		plainBytes, err := cipher.Decrypt(cipherBytes, nil)
+		if err != nil {
+			t.Errorf("Did not expect a decryption error but got %q", err)
+		}
+
+		if testData != string(plainBytes) {
+			t.Errorf("Expected %s but get %s", testData, string(plainBytes))
+		}
+	}
+}
+
+func TestGCMSIV_RejectsTamperedCiphertext(t *testing.T) {
+	keyBytes, _ := uuid.GenerateRandomBytes(16)
+	cipher, _ := NewGCMSIV(keyBytes)
+
+	cipherBytes, nonce, _ := cipher.Encrypt([]byte("my secret 1234"), false)
+	cipherBytes[0] ^= 1
+
+	if _, err := cipher.Decrypt(cipherBytes, nonce); err == nil {
+		t.Error("should be an error if the ciphertext has been modified")
+	}
+}
+
+func TestGCMSIV_InvalidKeySize(t *testing.T) {
+	keyBytes, _ := uuid.GenerateRandomBytes(24)
+
+	if _, err := NewGCMSIV(keyBytes); err == nil {
+		t.Error("should be an error for 24 bytes key")
+	}
+}
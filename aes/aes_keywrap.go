@@ -0,0 +1,178 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package aes
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+)
+
+const keyWrapSemiblock = 8
+
+var (
+	// ErrIntegrityCheck is returned when an unwrapped key fails the RFC 3394 or RFC 5649
+	// integrity check, i.e. the KEK is wrong or the wrapped key has been modified
+	ErrIntegrityCheck = errors.New("key wrap integrity check failed")
+
+	keyWrapDefaultIV = []byte{0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6, 0xa6}
+	keyWrapPadMagic  = []byte{0xa6, 0x59, 0x59, 0xa6}
+)
+
+// Wrap wraps the key with the AES key encryption key as per RFC 3394 (NIST SP 800-38F KW), the
+// key must be a multiple of 8 bytes and at least 16 bytes
+func Wrap(kekBytes []byte, keyBytes []byte) ([]byte, error) {
+	if len(keyBytes) < 2*keyWrapSemiblock || len(keyBytes)%keyWrapSemiblock != 0 {
+		return nil, errors.New("key to wrap must be a multiple of 8 bytes and at least 16 bytes")
+	}
+
+	block, err := aes.NewCipher(kekBytes)
+	if err != nil {
+		return nil, err
+	}
+	return wrap(block, keyWrapDefaultIV, keyBytes), nil
+}
+
+// Unwrap unwraps the RFC 3394 wrapped key with the AES key encryption key, ErrIntegrityCheck is
+// returned if the integrity check value does not tally
+func Unwrap(kekBytes []byte, wrappedBytes []byte) ([]byte, error) {
+	if len(wrappedBytes) < 3*keyWrapSemiblock || len(wrappedBytes)%keyWrapSemiblock != 0 {
+		return nil, errors.New("wrapped key must be a multiple of 8 bytes and at least 24 bytes")
+	}
+
+	block, err := aes.NewCipher(kekBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	iv, keyBytes := unwrap(block, wrappedBytes)
+	if subtle.ConstantTimeCompare(iv, keyWrapDefaultIV) != 1 {
+		return nil, ErrIntegrityCheck
+	}
+	return keyBytes, nil
+}
+
+// WrapWithPadding wraps a key of any non zero length with the AES key encryption key as per
+// RFC 5649 (NIST SP 800-38F KWP)
+func WrapWithPadding(kekBytes []byte, keyBytes []byte) ([]byte, error) {
+	if len(keyBytes) == 0 || uint64(len(keyBytes)) > 1<<32-1 {
+		return nil, errors.New("key to wrap must be between 1 and 2^32-1 bytes")
+	}
+
+	block, err := aes.NewCipher(kekBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	// The alternative IV carries the length of the key
+	iv := make([]byte, keyWrapSemiblock)
+	copy(iv, keyWrapPadMagic)
+	binary.BigEndian.PutUint32(iv[4:], uint32(len(keyBytes)))
+
+	padded := make([]byte, (len(keyBytes)+keyWrapSemiblock-1)/keyWrapSemiblock*keyWrapSemiblock)
+	copy(padded, keyBytes)
+
+	// A single semiblock is encrypted in one AES block operation
+	if len(padded) == keyWrapSemiblock {
+		wrapped := make([]byte, aes.BlockSize)
+		copy(wrapped, iv)
+		copy(wrapped[keyWrapSemiblock:], padded)
+		block.Encrypt(wrapped, wrapped)
+		return wrapped, nil
+	}
+	return wrap(block, iv, padded), nil
+}
+
+// UnwrapWithPadding unwraps the RFC 5649 wrapped key with the AES key encryption key,
+// ErrIntegrityCheck is returned if the integrity check value or the padding does not tally
+func UnwrapWithPadding(kekBytes []byte, wrappedBytes []byte) ([]byte, error) {
+	if len(wrappedBytes) < 2*keyWrapSemiblock || len(wrappedBytes)%keyWrapSemiblock != 0 {
+		return nil, errors.New("wrapped key must be a multiple of 8 bytes and at least 16 bytes")
+	}
+
+	block, err := aes.NewCipher(kekBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	var iv, padded []byte
+	if len(wrappedBytes) == aes.BlockSize {
+		plain := make([]byte, aes.BlockSize)
+		block.Decrypt(plain, wrappedBytes)
+		iv, padded = plain[:keyWrapSemiblock], plain[keyWrapSemiblock:]
+	} else {
+		iv, padded = unwrap(block, wrappedBytes)
+	}
+
+	// Check the magic, the length and the zero padding without branching on secret values
+	keyLength := int(binary.BigEndian.Uint32(iv[4:]) & 0x7fffffff)
+	ok := subtle.ConstantTimeCompare(iv[:4], keyWrapPadMagic)
+	ok &= subtle.ConstantTimeByteEq(iv[4]&0x80, 0)
+	ok &= subtle.ConstantTimeLessOrEq(len(padded)-keyWrapSemiblock+1, keyLength)
+	ok &= subtle.ConstantTimeLessOrEq(keyLength, len(padded))
+	var nonZero byte
+	for i := range padded {
+		nonZero |= padded[i] & byte(subtle.ConstantTimeLessOrEq(keyLength+1, i+1)*0xff)
+	}
+	ok &= subtle.ConstantTimeByteEq(nonZero, 0)
+	if ok != 1 {
+		return nil, ErrIntegrityCheck
+	}
+	return padded[:keyLength], nil
+}
+
+// wrap is the W wrapping function of NIST SP 800-38F with the given initial value
+func wrap(block cipher.Block, iv []byte, plain []byte) []byte {
+	n := len(plain) / keyWrapSemiblock
+	wrapped := make([]byte, keyWrapSemiblock+len(plain))
+	copy(wrapped, iv)
+	copy(wrapped[keyWrapSemiblock:], plain)
+
+	b := make([]byte, aes.BlockSize)
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			copy(b, wrapped[:keyWrapSemiblock])
+			copy(b[keyWrapSemiblock:], wrapped[i*keyWrapSemiblock:])
+			block.Encrypt(b, b)
+
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(wrapped, binary.BigEndian.Uint64(b)^t)
+			copy(wrapped[i*keyWrapSemiblock:], b[keyWrapSemiblock:])
+		}
+	}
+	return wrapped
+}
+
+// unwrap is the W^-1 unwrapping function of NIST SP 800-38F, it returns the recovered initial
+// value and the plain semiblocks
+func unwrap(block cipher.Block, wrapped []byte) ([]byte, []byte) {
+	n := len(wrapped)/keyWrapSemiblock - 1
+	plain := make([]byte, len(wrapped))
+	copy(plain, wrapped)
+
+	b := make([]byte, aes.BlockSize)
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(b, binary.BigEndian.Uint64(plain)^t)
+			copy(b[keyWrapSemiblock:], plain[i*keyWrapSemiblock:(i+1)*keyWrapSemiblock])
+			block.Decrypt(b, b)
+
+			copy(plain, b[:keyWrapSemiblock])
+			copy(plain[i*keyWrapSemiblock:], b[keyWrapSemiblock:])
+		}
+	}
+	return plain[:keyWrapSemiblock], plain[keyWrapSemiblock:]
+}
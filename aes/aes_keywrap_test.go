@@ -0,0 +1,127 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package aes
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestKeyWrap_RFC3394Vectors(t *testing.T) {
+	testDatas := []struct {
+		kek, key, wrapped string
+	}{
+		// RFC 3394 sections 4.1, 4.2, 4.3 and 4.6
+		{"000102030405060708090A0B0C0D0E0F", "00112233445566778899AABBCCDDEEFF", "1FA68B0A8112B447AEF34BD8FB5A7B829D3E862371D2CFE5"},
+		{"000102030405060708090A0B0C0D0E0F1011121314151617", "00112233445566778899AABBCCDDEEFF", "96778B25AE6CA435F92B5B97C050AED2468AB8A17AD84E5D"},
+		{"000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F", "00112233445566778899AABBCCDDEEFF", "64E8C3F9CE0F5BA263E9777905818A2A93C8191E7D6E8AE7"},
+		{"000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F", "00112233445566778899AABBCCDDEEFF000102030405060708090A0B0C0D0E0F", "28C9F404C4B810F4CBCCB35CFB87F8263F5786E2D80ED326CBC7F0E71A99F43BFB988B9B7A02DD21"},
+	}
+
+	for _, testData := range testDatas {
+		kek, _ := hex.DecodeString(testData.kek)
+		key, _ := hex.DecodeString(testData.key)
+
+		wrapped, err := Wrap(kek, key)
+		if err != nil {
+			t.Errorf("Did not expect an error but got %q", err)
+		}
+		if !strings.EqualFold(hex.EncodeToString(wrapped), testData.wrapped) {
+			t.Errorf("Expected value %s but got %x instead", testData.wrapped, wrapped)
+		}
+
+		unwrapped, err := Unwrap(kek, wrapped)
+		if err != nil {
+			t.Errorf("Did not expect an error but got %q", err)
+		}
+		if !strings.EqualFold(hex.EncodeToString(unwrapped), testData.key) {
+			t.Errorf("Expected value %s but got %x instead", testData.key, unwrapped)
+		}
+	}
+}
+
+func TestKeyWrapWithPadding_RFC5649Vectors(t *testing.T) {
+	kek, _ := hex.DecodeString("5840df6e29b02af1ab493b705bf16ea1ae8338f4dcc176a8")
+
+	testData := map[string]string{
+		"c37b7e6492584340bed12207808941155068f738": "138bdeaa9b8fa7fc61f97742e72248ee5ae6ae5360d1ae6a5f54f373fa543b6a",
+		"466f7250617369":                           "afbeb0f07dfbf5419200f2ccb50bb24f",
+	}
+
+	for key, expectedWrapped := range testData {
+		keyBytes, _ := hex.DecodeString(key)
+
+		wrapped, err := WrapWithPadding(kek, keyBytes)
+		if err != nil {
+			t.Errorf("Did not expect an error but got %q", err)
+		}
+		if hex.EncodeToString(wrapped) != expectedWrapped {
+			t.Errorf("Expected value %s but got %x instead", expectedWrapped, wrapped)
+		}
+
+		unwrapped, err := UnwrapWithPadding(kek, wrapped)
+		if err != nil {
+			t.Errorf("Did not expect an error but got %q", err)
+		}
+		if hex.EncodeToString(unwrapped) != key {
+			t.Errorf("Expected value %s but got %x instead", key, unwrapped)
+		}
+	}
+}
+
+func TestKeyWrap_IntegrityCheck(t *testing.T) {
+	kek, _ := hex.DecodeString("000102030405060708090A0B0C0D0E0F")
+	otherKek, _ := hex.DecodeString("0F0E0D0C0B0A09080706050403020100")
+	key, _ := hex.DecodeString("00112233445566778899AABBCCDDEEFF")
+
+	wrapped, _ := Wrap(kek, key)
+	if _, err := Unwrap(otherKek, wrapped); !errors.Is(err, ErrIntegrityCheck) {
+		t.Errorf("Expected an integrity check error but got %v", err)
+	}
+
+	wrapped[len(wrapped)-1] ^= 1
+	if _, err := Unwrap(kek, wrapped); !errors.Is(err, ErrIntegrityCheck) {
+		t.Errorf("Expected an integrity check error but got %v", err)
+	}
+
+	padded, _ := WrapWithPadding(kek, key[:5])
+	if _, err := UnwrapWithPadding(otherKek, padded); !errors.Is(err, ErrIntegrityCheck) {
+		t.Errorf("Expected an integrity check error but got %v", err)
+	}
+
+	// A KW output does not pass the KWP check
+	if _, err := UnwrapWithPadding(kek, wrapped); !errors.Is(err, ErrIntegrityCheck) {
+		t.Errorf("Expected an integrity check error but got %v", err)
+	}
+}
+
+func TestKeyWrap_InvalidInputs(t *testing.T) {
+	kek, _ := hex.DecodeString("000102030405060708090A0B0C0D0E0F")
+
+	if _, err := Wrap(kek, make([]byte, 12)); err == nil {
+		t.Error("should be an error if the key is not a multiple of 8 bytes")
+	}
+	if _, err := Wrap(kek[:10], make([]byte, 16)); err == nil {
+		t.Error("should be an error if the KEK is not a valid AES key")
+	}
+	if _, err := Unwrap(kek, make([]byte, 16)); err == nil {
+		t.Error("should be an error if the wrapped key is too short")
+	}
+	if _, err := WrapWithPadding(kek, nil); err == nil {
+		t.Error("should be an error if the key is empty")
+	}
+	if _, err := UnwrapWithPadding(kek, make([]byte, 20)); err == nil {
+		t.Error("should be an error if the wrapped key is not a multiple of 8 bytes")
+	}
+}
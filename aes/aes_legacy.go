@@ -0,0 +1,315 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package aes
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"io"
+	"slices"
+)
+
+// Mode is a non authenticated AES mode of operation
+type Mode int
+
+const (
+	// ECB encrypts every block independently, equal blocks give equal ciphertexts
+	ECB Mode = iota + 1
+	// CBC chains every block with the previous ciphertext block, starting with a random IV
+	CBC
+	// CTR encrypts a counter starting at a random IV and XORs it with the input
+	CTR
+)
+
+// Padding is the padding applied to the input of the ECB and CBC modes
+type Padding int
+
+const (
+	// NoPadding requires the input to be a multiple of the block size
+	NoPadding Padding = iota
+	// PKCS7Padding pads the input with the number of padding bytes, as per RFC 5652
+	PKCS7Padding
+)
+
+var (
+	// ErrUnauthenticated is returned when a non authenticated mode is constructed without opting in
+	ErrUnauthenticated = errors.New("unauthenticated AES modes must be explicitly allowed")
+
+	errInvalidPadding = errors.New("invalid padding")
+)
+
+// LegacyConfig selects the mode and padding of a LegacyCipher. These modes do not detect any
+// modification of the ciphertext, AllowUnauthenticated must be set to acknowledge it.
+type LegacyConfig struct {
+	Mode                 Mode
+	Padding              Padding
+	AllowUnauthenticated bool
+}
+
+// LegacyCipher is a non authenticated AES ECB, CBC or CTR cipher for interoperability with
+// legacy systems, prefer Cipher or NewEncryptThenMAC for anything new
+type LegacyCipher struct {
+	mode     legacyMode
+	KeyBytes []byte
+}
+
+// NewLegacy constructs a new non authenticated AES cipher using the raw key bytes provided, the
+// raw bytes must be either 16, 24, or 32 bytes
+func NewLegacy(keyBytes []byte, config LegacyConfig) (LegacyCipher, error) {
+	if !config.AllowUnauthenticated {
+		return LegacyCipher{}, ErrUnauthenticated
+	}
+
+	mode, err := newLegacyMode(keyBytes, config.Mode, config.Padding)
+	if err != nil {
+		return LegacyCipher{}, err
+	}
+	return LegacyCipher{mode, keyBytes}, nil
+}
+
+// Encrypt takes plain bytes and output cipher bytes, the random IV will be prefixed to cipher
+// bytes if prefixIV is true. ECB does not use an IV and returns a nil one.
+func (cipher *LegacyCipher) Encrypt(plainBytes []byte, prefixIV bool) ([]byte, []byte, error) {
+	var iv []byte
+	if cipher.mode.mode != ECB {
+		iv = make([]byte, aes.BlockSize)
+		if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	cipherBytes, err := cipher.mode.encrypt(nil, iv, plainBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	if prefixIV && iv != nil {
+		cipherBytes = append(append([]byte(nil), iv...), cipherBytes...)
+	}
+
+	return cipherBytes, iv, nil
+}
+
+// Decrypt takes cipher bytes and output plain bytes, it is assumed the IV is prefixed to cipher
+// bytes if its value is not being provided
+func (cipher *LegacyCipher) Decrypt(cipherBytes []byte, iv []byte) ([]byte, error) {
+	if iv == nil && cipher.mode.mode != ECB {
+		if len(cipherBytes) < aes.BlockSize {
+			return nil, errors.New("cipher bytes are shorter than the IV")
+		}
+		iv, cipherBytes = cipherBytes[:aes.BlockSize], cipherBytes[aes.BlockSize:]
+	}
+
+	return cipher.mode.decrypt(nil, iv, cipherBytes)
+}
+
+// NewEncryptThenMAC constructs a new authenticated Cipher out of AES-CBC or AES-CTR and
+// HMAC-SHA256, for partners who cannot use AES-GCM. The HMAC covers the IV, the ciphertext and
+// the additional data, and it is verified before anything is decrypted. The encryption and MAC
+// keys must be independent, the MAC key must be at least 32 bytes. CBC requires unpredictable IVs,
+// so only the random nonce source is accepted with it.
+func NewEncryptThenMAC(keyBytes []byte, macKeyBytes []byte, mode Mode, padding Padding, opts ...Option) (Cipher, error) {
+	if mode != CBC && mode != CTR {
+		return Cipher{}, errors.New("encrypt-then-MAC requires the CBC or CTR mode")
+	}
+	if mode == CBC && padding != PKCS7Padding {
+		return Cipher{}, errors.New("CBC encrypt-then-MAC requires PKCS7 padding")
+	}
+	if len(macKeyBytes) < sha256.Size {
+		return Cipher{}, errors.New("MAC key must be at least 32 bytes")
+	}
+
+	legacy, err := newLegacyMode(keyBytes, mode, padding)
+	if err != nil {
+		return Cipher{}, err
+	}
+
+	aead := &encryptThenMAC{legacy, append([]byte(nil), macKeyBytes...)}
+	c := newCipher(aead, keyBytes, opts)
+	if _, random := c.nonces.(randomNonces); mode == CBC && !random {
+		return Cipher{}, errors.New("CBC encrypt-then-MAC requires random IVs")
+	}
+	return c, nil
+}
+
+// legacyMode runs one of the non authenticated modes with the padding
+type legacyMode struct {
+	block   cipher.Block
+	mode    Mode
+	padding Padding
+}
+
+func newLegacyMode(keyBytes []byte, mode Mode, padding Padding) (legacyMode, error) {
+	switch mode {
+	case ECB, CBC:
+		if padding != NoPadding && padding != PKCS7Padding {
+			return legacyMode{}, errors.New("unknown padding")
+		}
+	case CTR:
+		if padding != NoPadding {
+			return legacyMode{}, errors.New("CTR mode does not use padding")
+		}
+	default:
+		return legacyMode{}, errors.New("unknown AES mode")
+	}
+
+	block, err := aes.NewCipher(keyBytes)
+	if err != nil {
+		return legacyMode{}, err
+	}
+	return legacyMode{block, mode, padding}, nil
+}
+
+// encrypt appends the encryption of the plain bytes to dst
+func (m legacyMode) encrypt(dst, iv, plainBytes []byte) ([]byte, error) {
+	if m.mode == CTR {
+		ret := slices.Grow(dst, len(plainBytes))[:len(dst)+len(plainBytes)]
+		out := ret[len(dst):]
+		cipher.NewCTR(m.block, iv).XORKeyStream(out, plainBytes)
+		return ret, nil
+	}
+
+	length := len(plainBytes)
+	if m.padding == PKCS7Padding {
+		length += aes.BlockSize - len(plainBytes)%aes.BlockSize
+	} else if length%aes.BlockSize != 0 {
+		return nil, errors.New("input must be a multiple of the block size without padding")
+	}
+
+	ret := slices.Grow(dst, length)[:len(dst)+length]
+	out := ret[len(dst):]
+	copy(out, plainBytes)
+	for i := len(plainBytes); i < length; i++ {
+		out[i] = byte(length - len(plainBytes))
+	}
+
+	if m.mode == CBC {
+		cipher.NewCBCEncrypter(m.block, iv).CryptBlocks(out, out)
+	} else {
+		for i := 0; i < length; i += aes.BlockSize {
+			m.block.Encrypt(out[i:], out[i:])
+		}
+	}
+	return ret, nil
+}
+
+// decrypt appends the decryption of the cipher bytes to dst
+func (m legacyMode) decrypt(dst, iv, cipherBytes []byte) ([]byte, error) {
+	if m.mode != ECB && len(iv) != aes.BlockSize {
+		return nil, errors.New("IV must be 16 bytes")
+	}
+
+	ret := slices.Grow(dst, len(cipherBytes))[:len(dst)+len(cipherBytes)]
+	out := ret[len(dst):]
+	if m.mode == CTR {
+		cipher.NewCTR(m.block, iv).XORKeyStream(out, cipherBytes)
+		return ret, nil
+	}
+
+	if len(cipherBytes)%aes.BlockSize != 0 || (m.padding == PKCS7Padding && len(cipherBytes) == 0) {
+		return nil, errors.New("input must be a multiple of the block size")
+	}
+	if m.mode == CBC {
+		cipher.NewCBCDecrypter(m.block, iv).CryptBlocks(out, cipherBytes)
+	} else {
+		for i := 0; i < len(cipherBytes); i += aes.BlockSize {
+			m.block.Decrypt(out[i:], cipherBytes[i:])
+		}
+	}
+
+	if m.padding == NoPadding {
+		return ret, nil
+	}
+	n, err := pkcs7PaddingLength(out)
+	if err != nil {
+		return nil, err
+	}
+	return ret[:len(ret)-n], nil
+}
+
+// pkcs7PaddingLength checks the padding of the last block without branching on its content
+func pkcs7PaddingLength(padded []byte) (int, error) {
+	last := padded[len(padded)-aes.BlockSize:]
+	n := last[aes.BlockSize-1]
+
+	ok := subtle.ConstantTimeLessOrEq(1, int(n)) & subtle.ConstantTimeLessOrEq(int(n), aes.BlockSize)
+	for i := 0; i < aes.BlockSize; i++ {
+		inPadding := subtle.ConstantTimeLessOrEq(aes.BlockSize-int(n), i)
+		ok &= subtle.ConstantTimeSelect(inPadding, subtle.ConstantTimeByteEq(last[i], n), 1)
+	}
+	if ok != 1 {
+		return 0, errInvalidPadding
+	}
+	return int(n), nil
+}
+
+// encryptThenMAC implements cipher.AEAD as ciphertext || HMAC-SHA256(IV || ciphertext || AD ||
+// bit length of AD), the nonce being the IV
+type encryptThenMAC struct {
+	mode   legacyMode
+	macKey []byte
+}
+
+func (e *encryptThenMAC) NonceSize() int {
+	return aes.BlockSize
+}
+
+func (e *encryptThenMAC) Overhead() int {
+	if e.mode.padding == PKCS7Padding {
+		return aes.BlockSize + sha256.Size
+	}
+	return sha256.Size
+}
+
+func (e *encryptThenMAC) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != aes.BlockSize {
+		panic("aes: incorrect nonce length given to encrypt-then-MAC")
+	}
+
+	// The constructor only allows modes that encrypt inputs of any length
+	ret, _ := e.mode.encrypt(dst, nonce, plaintext)
+	return append(ret, e.tag(nonce, ret[len(dst):], additionalData)...)
+}
+
+func (e *encryptThenMAC) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != aes.BlockSize {
+		panic("aes: incorrect nonce length given to encrypt-then-MAC")
+	}
+	if len(ciphertext) < sha256.Size {
+		return nil, errors.New("cipher: message authentication failed")
+	}
+
+	tag := ciphertext[len(ciphertext)-sha256.Size:]
+	ciphertext = ciphertext[:len(ciphertext)-sha256.Size]
+	if !hmac.Equal(tag, e.tag(nonce, ciphertext, additionalData)) {
+		return nil, errors.New("cipher: message authentication failed")
+	}
+	return e.mode.decrypt(dst, nonce, ciphertext)
+}
+
+func (e *encryptThenMAC) tag(iv, ciphertext, additionalData []byte) []byte {
+	mac := hmac.New(sha256.New, e.macKey)
+	mac.Write(iv)
+	mac.Write(ciphertext)
+	mac.Write(additionalData)
+
+	var length [8]byte
+	binary.BigEndian.PutUint64(length[:], uint64(len(additionalData))*8)
+	mac.Write(length[:])
+	return mac.Sum(nil)
+}
@@ -0,0 +1,168 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package aes
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/hashicorp/go-uuid"
+)
+
+// NIST SP 800-38A, appendix F
+const sp80038APlain = "6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e51" +
+	"30c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710"
+
+func TestLegacy_SP80038A(t *testing.T) {
+	testData := []struct {
+		mode     Mode
+		iv       string
+		expected string
+	}{
+		{ECB, "", "3ad77bb40d7a3660a89ecaf32466ef97f5d3d58503b9699de785895a96fdbaaf" +
+			"43b1cd7f598ece23881b00e3ed0306887b0c785e27e8ad3f8223207104725dd4"},
+		{CBC, "000102030405060708090a0b0c0d0e0f", "7649abac8119b246cee98e9b12e9197d5086cb9b507219ee95db113a917678b2" +
+			"73bed6b8e3c1743b7116e69e222295163ff1caa1681fac09120eca307586e1a7"},
+		{CTR, "f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff", "874d6191b620e3261bef6864990db6ce9806f66b7970fdff8617187bb9fffdff" +
+			"5ae4df3edbd5d35e5b4f09020db03eab1e031dda2fbe03d1792170a0f3009cee"},
+	}
+
+	keyBytes, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+	plainBytes, _ := hex.DecodeString(sp80038APlain)
+	for _, data := range testData {
+		cipher, err := NewLegacy(keyBytes, LegacyConfig{Mode: data.mode, AllowUnauthenticated: true})
+		if err != nil {
+			t.Fatalf("Did not expect an error but got %q", err)
+		}
+
+		var iv []byte
+		if data.iv != "" {
+			iv, _ = hex.DecodeString(data.iv)
+		}
+		cipherBytes, _ := cipher.mode.encrypt(nil, iv, plainBytes)
+		if hex.EncodeToString(cipherBytes) != data.expected {
+			t.Errorf("Expected %s but got %x", data.expected, cipherBytes)
+		}
+
+		decrypted, err := cipher.Decrypt(cipherBytes, iv)
+		if err != nil || !bytes.Equal(decrypted, plainBytes) {
+			t.Errorf("Expected %x but got %x (%v)", plainBytes, decrypted, err)
+		}
+	}
+}
+
+func TestLegacy_PKCS7(t *testing.T) {
+	// openssl enc -aes-128-cbc -K 2b7e151628aed2a6abf7158809cf4f3c -iv 000102030405060708090a0b0c0d0e0f
+	keyBytes, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+	iv, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	cipherBytes, _ := hex.DecodeString("2b8f53e2de92032df7f3ab2176b5d4029cc50976f776aa2bbfb0518b2dce1efb")
+
+	cipher, _ := NewLegacy(keyBytes, LegacyConfig{Mode: CBC, Padding: PKCS7Padding, AllowUnauthenticated: true})
+	plainBytes, err := cipher.Decrypt(cipherBytes, iv)
+	if err != nil || string(plainBytes) != "Partner bank payload" {
+		t.Errorf("Expected Partner bank payload but got %s (%v)", plainBytes, err)
+	}
+
+	for _, length := range []int{0, 1, 15, 16, 17, 32} {
+		plainBytes, _ := uuid.GenerateRandomBytes(length)
+		cipherBytes, _, err := cipher.Encrypt(plainBytes, true)
+		if err != nil {
+			t.Fatalf("Did not expect an error but got %q", err)
+		}
+		if len(cipherBytes) != 16+(length/16+1)*16 {
+			t.Errorf("Unexpected cipher bytes length %d for %d plain bytes", len(cipherBytes), length)
+		}
+
+		decrypted, err := cipher.Decrypt(cipherBytes, nil)
+		if err != nil || !bytes.Equal(decrypted, plainBytes) {
+			t.Errorf("Expected %x but got %x (%v)", plainBytes, decrypted, err)
+		}
+	}
+
+	cipherBytes[len(cipherBytes)-1] ^= 0x01
+	if _, err := cipher.Decrypt(cipherBytes, iv); err == nil {
+		t.Error("should be an error if the padding is invalid")
+	}
+}
+
+func TestLegacy_Validation(t *testing.T) {
+	keyBytes, _ := uuid.GenerateRandomBytes(32)
+
+	if _, err := NewLegacy(keyBytes, LegacyConfig{Mode: CBC, Padding: PKCS7Padding}); err != ErrUnauthenticated {
+		t.Errorf("Expected ErrUnauthenticated but got %v", err)
+	}
+	if _, err := NewLegacy(keyBytes, LegacyConfig{Mode: CTR, Padding: PKCS7Padding, AllowUnauthenticated: true}); err == nil {
+		t.Error("should be an error if CTR is used with padding")
+	}
+	if _, err := NewLegacy(keyBytes[:10], LegacyConfig{Mode: ECB, AllowUnauthenticated: true}); err == nil {
+		t.Error("should be an error if the key size is invalid")
+	}
+
+	cipher, _ := NewLegacy(keyBytes, LegacyConfig{Mode: ECB, AllowUnauthenticated: true})
+	if _, _, err := cipher.Encrypt([]byte("not a block"), true); err == nil {
+		t.Error("should be an error if the input is not a multiple of the block size without padding")
+	}
+}
+
+func TestEncryptThenMAC(t *testing.T) {
+	keyBytes, _ := uuid.GenerateRandomBytes(32)
+	macKeyBytes, _ := uuid.GenerateRandomBytes(32)
+
+	for _, mode := range []Mode{CBC, CTR} {
+		padding := NoPadding
+		if mode == CBC {
+			padding = PKCS7Padding
+		}
+
+		cipher, err := NewEncryptThenMAC(keyBytes, macKeyBytes, mode, padding)
+		if err != nil {
+			t.Fatalf("Did not expect an error but got %q", err)
+		}
+
+		cipherBytes, _, _ := cipher.Encrypt([]byte("my secret 1234"), true)
+		plainBytes, err := cipher.Decrypt(cipherBytes, nil)
+		if err != nil || string(plainBytes) != "my secret 1234" {
+			t.Errorf("Expected my secret 1234 but got %s (%v)", plainBytes, err)
+		}
+
+		for i := range cipherBytes {
+			tampered := append([]byte(nil), cipherBytes...)
+			tampered[i] ^= 0x01
+			if _, err := cipher.Decrypt(tampered, nil); err == nil {
+				t.Fatalf("should be an error if byte %d is modified", i)
+			}
+		}
+	}
+
+	if _, err := NewEncryptThenMAC(keyBytes, macKeyBytes, ECB, PKCS7Padding); err == nil {
+		t.Error("should be an error if ECB is used")
+	}
+	if _, err := NewEncryptThenMAC(keyBytes, macKeyBytes, CBC, NoPadding); err == nil {
+		t.Error("should be an error if CBC is used without padding")
+	}
+	if _, err := NewEncryptThenMAC(keyBytes, macKeyBytes[:16], CTR, NoPadding); err == nil {
+		t.Error("should be an error if the MAC key is too short")
+	}
+
+	counter, _ := NewCounterNonces([]byte("node"), 16, &memoryCounterState{}, 10)
+	if _, err := NewEncryptThenMAC(keyBytes, macKeyBytes, CBC, PKCS7Padding, WithNonceSource(counter)); err == nil {
+		t.Error("should be an error if CBC is used with counter IVs")
+	}
+	fixed := NonceFunc(func(nonce []byte) error { return nil })
+	if _, err := NewEncryptThenMAC(keyBytes, macKeyBytes, CBC, PKCS7Padding, WithNonceSource(fixed)); err == nil {
+		t.Error("should be an error if CBC is used with a nonce function")
+	}
+	if _, err := NewEncryptThenMAC(keyBytes, macKeyBytes, CTR, NoPadding, WithNonceSource(counter)); err != nil {
+		t.Errorf("Did not expect an error for CTR with counter IVs but got %q", err)
+	}
+}
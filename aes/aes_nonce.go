@@ -0,0 +1,216 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package aes
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// minCounterBytes is the minimum width of the counter part of a counter nonce
+const minCounterBytes = 4
+
+// ErrNoncesExhausted is returned when a counter nonce source has issued every counter value
+var ErrNoncesExhausted = errors.New("nonce counter exhausted")
+
+// NonceSource generates the nonces of the Cipher
+type NonceSource interface {
+	// FillNonce writes the next nonce into the slice, whose length is the cipher nonce size
+	FillNonce(nonce []byte) error
+}
+
+// NonceFunc adapts a caller supplied function to a NonceSource
+type NonceFunc func(nonce []byte) error
+
+// FillNonce calls the function
+func (f NonceFunc) FillNonce(nonce []byte) error {
+	return f(nonce)
+}
+
+// WithNonceSource replaces the default random nonces of the Cipher
+func WithNonceSource(source NonceSource) Option {
+	return func(cipher *Cipher) {
+		cipher.nonces = source
+	}
+}
+
+// RandomNonces returns the default nonce source, reading every nonce from crypto/rand
+func RandomNonces() NonceSource {
+	return randomNonces{}
+}
+
+type randomNonces struct{}
+
+func (randomNonces) FillNonce(nonce []byte) error {
+	_, err := io.ReadFull(rand.Reader, nonce)
+	return err
+}
+
+// CounterState persists the high water mark of a counter nonce source
+type CounterState interface {
+	// Load returns the persisted value, zero if nothing has been persisted yet
+	Load() (uint64, error)
+	// Store durably persists the value before returning
+	Store(value uint64) error
+}
+
+// CounterNonces generates deterministic nonces made of a fixed prefix followed by a big endian
+// counter. Counter values are reserved in blocks: the end of the block is persisted before any
+// value of the block is issued, so that a restart after a crash resumes after every nonce which
+// may have been used and a nonce is never reused. It is meant for a single writer per prefix.
+type CounterNonces struct {
+	mu           sync.Mutex
+	prefix       []byte
+	counterBytes int
+	state        CounterState
+	reserve      uint64
+	next         uint64
+	limit        uint64
+}
+
+// NewCounterNonces constructs a counter nonce source for nonces of nonceSize bytes, e.g. 12 for
+// AES-GCM, resuming from the persisted state. The prefix must leave at least 4 bytes for the
+// counter, and reserve is the number of counter values reserved on every write of the state.
+func NewCounterNonces(prefix []byte, nonceSize int, state CounterState, reserve uint64) (*CounterNonces, error) {
+	if nonceSize-len(prefix) < minCounterBytes {
+		return nil, fmt.Errorf("nonce prefix leaves less than %d bytes for the counter", minCounterBytes)
+	}
+	if reserve == 0 {
+		return nil, errors.New("counter reserve must be positive")
+	}
+
+	next, err := state.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	counter := &CounterNonces{
+		prefix:       append([]byte(nil), prefix...),
+		counterBytes: nonceSize - len(prefix),
+		state:        state,
+		reserve:      reserve,
+		next:         next,
+		limit:        next,
+	}
+	if err := counter.reserveBlock(); err != nil {
+		return nil, err
+	}
+	return counter, nil
+}
+
+// FillNonce writes the prefix and the next counter value into the nonce, which must have the size
+// given to NewCounterNonces
+func (c *CounterNonces) FillNonce(nonce []byte) error {
+	counterBytes := c.counterBytes
+	if len(nonce) != len(c.prefix)+counterBytes {
+		return fmt.Errorf("counter nonces are %d bytes", len(c.prefix)+counterBytes)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if counterBytes < 8 && c.next >= 1<<(8*counterBytes) {
+		return ErrNoncesExhausted
+	}
+	if c.next == c.limit {
+		if err := c.reserveBlock(); err != nil {
+			return err
+		}
+	}
+
+	copy(nonce, c.prefix)
+	counter := nonce[len(c.prefix):]
+	for i := range counter {
+		counter[i] = 0
+	}
+	var value [8]byte
+	binary.BigEndian.PutUint64(value[:], c.next)
+	if counterBytes >= 8 {
+		copy(counter[counterBytes-8:], value[:])
+	} else {
+		copy(counter, value[8-counterBytes:])
+	}
+
+	c.next++
+	return nil
+}
+
+// reserveBlock persists the end of the next block of counter values
+func (c *CounterNonces) reserveBlock() error {
+	if c.limit > ^uint64(0)-c.reserve {
+		return ErrNoncesExhausted
+	}
+	limit := c.limit + c.reserve
+	if err := c.state.Store(limit); err != nil {
+		return err
+	}
+	c.limit = limit
+	return nil
+}
+
+// FileCounterState is a CounterState persisted in a file, each store atomically replaces the file
+type FileCounterState struct {
+	Path string
+}
+
+// Load reads the persisted value, a missing file is a zero value
+func (f FileCounterState) Load() (uint64, error) {
+	content, err := os.ReadFile(f.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(content)), 10, 64)
+}
+
+// Store writes the value to a temporary file which is synced and renamed over the state file, the
+// directory is synced afterwards so that the rename survives a crash
+func (f FileCounterState) Store(value uint64) error {
+	tmp, err := os.CreateTemp(filepath.Dir(f.Path), filepath.Base(f.Path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(strconv.FormatUint(value, 10)); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), f.Path); err != nil {
+		return err
+	}
+
+	dir, err := os.Open(filepath.Dir(f.Path))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
@@ -0,0 +1,140 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package aes
+
+import (
+	"encoding/hex"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/go-uuid"
+)
+
+type memoryCounterState struct {
+	value  uint64
+	stores int
+}
+
+func (m *memoryCounterState) Load() (uint64, error) {
+	return m.value, nil
+}
+
+func (m *memoryCounterState) Store(value uint64) error {
+	m.value = value
+	m.stores++
+	return nil
+}
+
+func TestCounterNonces_Sequence(t *testing.T) {
+	state := &memoryCounterState{}
+	nonces, err := NewCounterNonces([]byte{0xca, 0xfe, 0xba, 0xbe}, 12, state, 2)
+	if err != nil {
+		t.Fatalf("Did not expect an error but got %q", err)
+	}
+
+	expectedNonces := []string{
+		"cafebabe0000000000000000",
+		"cafebabe0000000000000001",
+		"cafebabe0000000000000002",
+	}
+	for _, expected := range expectedNonces {
+		nonce := make([]byte, 12)
+		if err := nonces.FillNonce(nonce); err != nil {
+			t.Errorf("Did not expect an error but got %q", err)
+		}
+		if hex.EncodeToString(nonce) != expected {
+			t.Errorf("Expected nonce %s but got %x", expected, nonce)
+		}
+	}
+
+	// The reservation is always ahead of the issued counters
+	if state.value != 4 || state.stores != 2 {
+		t.Errorf("Expected the state to be reserved up to 4 in 2 stores but got %d in %d", state.value, state.stores)
+	}
+}
+
+func TestCounterNonces_ResumeAfterCrash(t *testing.T) {
+	state := FileCounterState{Path: filepath.Join(t.TempDir(), "nonce.counter")}
+
+	first, _ := NewCounterNonces([]byte("tst1"), 12, state, 100)
+	nonce := make([]byte, 12)
+	first.FillNonce(nonce)
+	first.FillNonce(nonce)
+
+	// A new source over the same state never reissues a counter the first one may have used
+	second, err := NewCounterNonces([]byte("tst1"), 12, state, 100)
+	if err != nil {
+		t.Fatalf("Did not expect an error but got %q", err)
+	}
+	second.FillNonce(nonce)
+	if hex.EncodeToString(nonce[4:]) != "0000000000000064" {
+		t.Errorf("Expected the counter to resume at 100 but got %x", nonce[4:])
+	}
+}
+
+func TestCounterNonces_Exhausted(t *testing.T) {
+	state := &memoryCounterState{value: 1<<32 - 1}
+	nonces, _ := NewCounterNonces(make([]byte, 8), 12, state, 10)
+
+	nonce := make([]byte, 12)
+	if err := nonces.FillNonce(nonce); err != nil {
+		t.Errorf("Did not expect an error but got %q", err)
+	}
+	if err := nonces.FillNonce(nonce); !errors.Is(err, ErrNoncesExhausted) {
+		t.Errorf("Expected an exhausted error but got %v", err)
+	}
+
+	if err := nonces.FillNonce(make([]byte, 16)); err == nil {
+		t.Error("should be an error if the nonce size differs")
+	}
+
+	// A prefix leaving less than 4 bytes for the counter is rejected at construction
+	if _, err := NewCounterNonces(make([]byte, 9), 12, &memoryCounterState{}, 10); err == nil {
+		t.Error("should be an error if the prefix leaves less than 4 bytes for the counter")
+	}
+}
+
+func TestAESCipher_NonceSources(t *testing.T) {
+	keyBytes, _ := uuid.GenerateRandomBytes(32)
+	counter, _ := NewCounterNonces([]byte("node"), 12, &memoryCounterState{}, 1000)
+
+	fixed := NonceFunc(func(nonce []byte) error {
+		copy(nonce, "caller nonce")
+		return nil
+	})
+	failing := NonceFunc(func(nonce []byte) error {
+		return errors.New("no nonce")
+	})
+
+	counterCipher, _ := New(keyBytes, WithNonceSource(counter))
+	cipherBytes, nonce, err := counterCipher.Encrypt([]byte("my secret 1234"), true)
+	if err != nil {
+		t.Errorf("Did not expect an encryption error but got %q", err)
+	}
+	if hex.EncodeToString(nonce) != hex.EncodeToString([]byte("node"))+"0000000000000000" {
+		t.Errorf("Expected the first counter nonce but got %x", nonce)
+	}
+	if plainBytes, err := counterCipher.Decrypt(cipherBytes, nil); err != nil || string(plainBytes) != "my secret 1234" {
+		t.Errorf("Expected my secret 1234 but got %s (%v)", plainBytes, err)
+	}
+
+	fixedCipher, _ := New(keyBytes, WithNonceSource(fixed))
+	if _, nonce, _ := fixedCipher.Encrypt([]byte("my secret 1234"), false); string(nonce) != "caller nonce" {
+		t.Errorf("Expected the caller nonce but got %q", nonce)
+	}
+
+	failingCipher, _ := New(keyBytes, WithNonceSource(failing))
+	if _, _, err := failingCipher.Encrypt([]byte("my secret 1234"), false); err == nil {
+		t.Error("should be an error if the nonce source fails")
+	}
+}
@@ -0,0 +1,160 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package aes
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"errors"
+
+	"github.com/exohood/exohood-crypto-algorithms/cmac"
+)
+
+// sivMaxComponents is the maximum number of associated data components S2V accepts, the
+// plaintext being the last of the 127 vectors
+const sivMaxComponents = 126
+
+// DeterministicCipher is an AES-SIV (RFC 5297) cipher. Encryption is deterministic: the same
+// plaintext and associated data always produce the same ciphertext, which allows joins and
+// equality lookups on encrypted columns but also reveals to anyone holding the ciphertexts which
+// of them are equal. Add a nonce as the last associated data component to avoid the leak.
+type DeterministicCipher struct {
+	macBlock cipher.Block
+	ctrBlock cipher.Block
+	KeyBytes []byte
+}
+
+// NewSIV constructs a new AES-SIV cipher using the raw key bytes provided, the raw bytes must be
+// either 32, 48, or 64 bytes: the first half keys CMAC and the second half keys CTR
+func NewSIV(keyBytes []byte) (DeterministicCipher, error) {
+	if len(keyBytes) != 32 && len(keyBytes) != 48 && len(keyBytes) != 64 {
+		return DeterministicCipher{}, errors.New("AES-SIV key must be either 32, 48 or 64 bytes")
+	}
+
+	half := len(keyBytes) / 2
+	macBlock, err := aes.NewCipher(keyBytes[:half])
+	if err != nil {
+		return DeterministicCipher{}, err
+	}
+	ctrBlock, err := aes.NewCipher(keyBytes[half:])
+	if err != nil {
+		return DeterministicCipher{}, err
+	}
+
+	return DeterministicCipher{macBlock, ctrBlock, keyBytes}, nil
+}
+
+// EncryptDeterministic takes plain bytes and any number of associated data components and
+// output the synthetic IV followed by the cipher bytes. Equal inputs give equal outputs.
+func (cipher *DeterministicCipher) EncryptDeterministic(plainBytes []byte, associatedData ...[]byte) ([]byte, error) {
+	if len(associatedData) > sivMaxComponents {
+		return nil, errors.New("too many associated data components")
+	}
+
+	v, err := cipher.s2v(plainBytes, associatedData)
+	if err != nil {
+		return nil, err
+	}
+
+	cipherBytes := make([]byte, aes.BlockSize+len(plainBytes))
+	copy(cipherBytes, v)
+	cipher.ctr(v, cipherBytes[aes.BlockSize:], plainBytes)
+	return cipherBytes, nil
+}
+
+// DecryptDeterministic takes the synthetic IV prefixed cipher bytes and the associated data
+// components used for encryption, and output plain bytes
+func (cipher *DeterministicCipher) DecryptDeterministic(cipherBytes []byte, associatedData ...[]byte) ([]byte, error) {
+	if len(associatedData) > sivMaxComponents {
+		return nil, errors.New("too many associated data components")
+	}
+	if len(cipherBytes) < aes.BlockSize {
+		return nil, errors.New("ciphertext is too short")
+	}
+
+	v := cipherBytes[:aes.BlockSize]
+	plainBytes := make([]byte, len(cipherBytes)-aes.BlockSize)
+	cipher.ctr(v, plainBytes, cipherBytes[aes.BlockSize:])
+
+	expected, err := cipher.s2v(plainBytes, associatedData)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare(expected, v) != 1 {
+		return nil, errors.New("cipher: message authentication failed")
+	}
+	return plainBytes, nil
+}
+
+// s2v is the string to vector PRF of section 2.4 of RFC 5297 with the plaintext as last string
+func (cipher *DeterministicCipher) s2v(plainBytes []byte, associatedData [][]byte) ([]byte, error) {
+	mac, err := cmac.New(cipher.macBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	mac.Write(make([]byte, aes.BlockSize))
+	d := mac.Sum(nil)
+
+	for _, component := range associatedData {
+		mac.Reset()
+		mac.Write(component)
+		d = dbl(d)
+		subtle.XORBytes(d, d, mac.Sum(nil))
+	}
+
+	var t []byte
+	if len(plainBytes) >= aes.BlockSize {
+		t = make([]byte, len(plainBytes))
+		copy(t, plainBytes)
+		tail := t[len(t)-aes.BlockSize:]
+		subtle.XORBytes(tail, tail, d)
+	} else {
+		t = make([]byte, aes.BlockSize)
+		copy(t, plainBytes)
+		t[len(plainBytes)] = 0x80
+		subtle.XORBytes(t, t, dbl(d))
+	}
+
+	mac.Reset()
+	mac.Write(t)
+	return mac.Sum(nil), nil
+}
+
+// ctr runs AES-CTR from the synthetic IV with the 31st and 63rd bits cleared
+func (cipher *DeterministicCipher) ctr(v, dst, src []byte) {
+	q := make([]byte, aes.BlockSize)
+	copy(q, v)
+	q[8] &= 0x7f
+	q[12] &= 0x7f
+	xorKeyStreamCTR(cipher.ctrBlock, q, dst, src)
+}
+
+// xorKeyStreamCTR runs the standard CTR mode, it lives outside of the methods whose receiver
+// shadows the cipher package
+func xorKeyStreamCTR(block cipher.Block, iv, dst, src []byte) {
+	cipher.NewCTR(block, iv).XORKeyStream(dst, src)
+}
+
+// dbl multiplies the 128-bit block by x in GF(2^128), as CMAC does for its subkeys
+func dbl(in []byte) []byte {
+	out := make([]byte, len(in))
+	var carry byte
+	for i := len(in) - 1; i >= 0; i-- {
+		out[i] = in[i]<<1 | carry
+		carry = in[i] >> 7
+	}
+	out[len(out)-1] ^= byte(subtle.ConstantTimeSelect(int(carry), 0x87, 0))
+	return out
+}
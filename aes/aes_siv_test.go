@@ -0,0 +1,106 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package aes
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/hashicorp/go-uuid"
+)
+
+func TestSIV_RFC5297DeterministicVector(t *testing.T) {
+	// RFC 5297 appendix A.1
+	key, _ := hex.DecodeString("fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0f0f1f2f3f4f5f6f7f8f9fafbfcfdfeff")
+	ad, _ := hex.DecodeString("101112131415161718191a1b1c1d1e1f2021222324252627")
+	plaintext, _ := hex.DecodeString("112233445566778899aabbccddee")
+	expected := "85632d07c6e8f37f950acd320a2ecc9340c02b9690c4dc04daef7f6afe5c"
+
+	cipher, err := NewSIV(key)
+	if err != nil {
+		t.Fatalf("Did not expect an error but got %q", err)
+	}
+
+	cipherBytes, err := cipher.EncryptDeterministic(plaintext, ad)
+	if err != nil {
+		t.Errorf("Did not expect an encryption error but got %q", err)
+	}
+	if hex.EncodeToString(cipherBytes) != expected {
+		t.Errorf("Expected %s but got %x", expected, cipherBytes)
+	}
+
+	plainBytes, err := cipher.DecryptDeterministic(cipherBytes, ad)
+	if err != nil {
+		t.Errorf("Did not expect a decryption error but got %q", err)
+	}
+	if !bytes.Equal(plainBytes, plaintext) {
+		t.Errorf("Expected %x but got %x", plaintext, plainBytes)
+	}
+}
+
+func TestSIV_RFC5297NonceBasedVector(t *testing.T) {
+	// RFC 5297 appendix A.2, the nonce is passed as the last associated data component
+	key, _ := hex.DecodeString("7f7e7d7c7b7a79787776757473727170404142434445464748494a4b4c4d4e4f")
+	ad1, _ := hex.DecodeString("00112233445566778899aabbccddeeffdeaddadadeaddadaffeeddccbbaa99887766554433221100")
+	ad2, _ := hex.DecodeString("102030405060708090a0")
+	nonce, _ := hex.DecodeString("09f911029d74e35bd84156c5635688c0")
+	plaintext, _ := hex.DecodeString("7468697320697320736f6d6520706c61696e7465787420746f20656e6372797074207573696e67205349562d414553")
+	expected := "7bdb6e3b432667eb06f4d14bff2fbd0fcb900f2fddbe404326601965c889bf17dba77ceb094fa663b7a3f748ba8af829ea64ad544a272e9c485b62a3fd5c0d"
+
+	cipher, _ := NewSIV(key)
+
+	cipherBytes, err := cipher.EncryptDeterministic(plaintext, ad1, ad2, nonce)
+	if err != nil {
+		t.Errorf("Did not expect an encryption error but got %q", err)
+	}
+	if hex.EncodeToString(cipherBytes) != expected {
+		t.Errorf("Expected %s but got %x", expected, cipherBytes)
+	}
+
+	plainBytes, err := cipher.DecryptDeterministic(cipherBytes, ad1, ad2, nonce)
+	if err != nil {
+		t.Errorf("Did not expect a decryption error but got %q", err)
+	}
+	if !bytes.Equal(plainBytes, plaintext) {
+		t.Errorf("Expected %x but got %x", plaintext, plainBytes)
+	}
+}
+
+func TestSIV_EqualInputsGiveEqualCiphertexts(t *testing.T) {
+	keyBytes, _ := uuid.GenerateRandomBytes(64)
+	cipher, _ := NewSIV(keyBytes)
+
+	first, _ := cipher.EncryptDeterministic([]byte("4111111111111111"), []byte("pan"))
+	second, _ := cipher.EncryptDeterministic([]byte("4111111111111111"), []byte("pan"))
+	if !bytes.Equal(first, second) {
+		t.Error("Expected the same ciphertext for the same input")
+	}
+
+	third, _ := cipher.EncryptDeterministic([]byte("4111111111111111"), []byte("national-id"))
+	if bytes.Equal(first, third) {
+		t.Error("Expected a different ciphertext for different associated data")
+	}
+}
+
+func TestSIV_RejectsWrongAssociatedData(t *testing.T) {
+	keyBytes, _ := uuid.GenerateRandomBytes(32)
+	cipher, _ := NewSIV(keyBytes)
+
+	cipherBytes, _ := cipher.EncryptDeterministic([]byte("my secret 1234"), []byte("tenant-1"))
+	if _, err := cipher.DecryptDeterministic(cipherBytes, []byte("tenant-2")); err == nil {
+		t.Error("should be an error if the associated data does not match")
+	}
+	if _, err := cipher.DecryptDeterministic(cipherBytes[:10]); err == nil {
+		t.Error("should be an error if the ciphertext is too short")
+	}
+}
@@ -0,0 +1,96 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package aes
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"errors"
+)
+
+const xaesNonceSize = 24
+
+// NewXAES constructs a new XAES-256-GCM cipher using the raw key bytes provided, the raw bytes
+// must be 32 bytes. Every message is encrypted with AES-256-GCM under a key derived from the key
+// and the first half of its 192-bit random nonce, which makes random nonces safe for practically
+// unlimited messages under a single long-lived key.
+func NewXAES(keyBytes []byte, opts ...Option) (Cipher, error) {
+	if len(keyBytes) != 32 {
+		return Cipher{}, errors.New("XAES-256-GCM key must be 32 bytes")
+	}
+
+	block, err := aes.NewCipher(keyBytes)
+	if err != nil {
+		return Cipher{}, err
+	}
+
+	// K1 is the first CMAC subkey of the key, see c2sp.org/XAES-256-GCM
+	l := make([]byte, aes.BlockSize)
+	block.Encrypt(l, l)
+
+	return newCipher(&xaes{block, dbl(l)}, keyBytes, opts), nil
+}
+
+// xaes implements cipher.AEAD for XAES-256-GCM
+type xaes struct {
+	block cipher.Block
+	k1    []byte
+}
+
+func (x *xaes) NonceSize() int {
+	return xaesNonceSize
+}
+
+func (x *xaes) Overhead() int {
+	return 16
+}
+
+func (x *xaes) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != xaesNonceSize {
+		panic("aes: incorrect nonce length given to XAES-256-GCM")
+	}
+	return x.deriveGCM(nonce[:12]).Seal(dst, nonce[12:], plaintext, additionalData)
+}
+
+func (x *xaes) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != xaesNonceSize {
+		panic("aes: incorrect nonce length given to XAES-256-GCM")
+	}
+	return x.deriveGCM(nonce[:12]).Open(dst, nonce[12:], ciphertext, additionalData)
+}
+
+// deriveGCM derives the AES-256-GCM key for the first 96 bits of the nonce with the CMAC based
+// KDF in counter mode of NIST SP 800-108r1, the label being "X" and the context the nonce half
+func (x *xaes) deriveGCM(nonceHalf []byte) cipher.AEAD {
+	key := make([]byte, 2*aes.BlockSize)
+	m := key[:aes.BlockSize]
+	for i := byte(1); i <= 2; i++ {
+		m[0], m[1], m[2], m[3] = 0, i, 'X', 0
+		copy(m[4:], nonceHalf)
+		for j := range m {
+			m[j] ^= x.k1[j]
+		}
+		x.block.Encrypt(m, m)
+		m = key[aes.BlockSize:]
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		panic("aes: failed to derive XAES-256-GCM key")
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		panic("aes: failed to derive XAES-256-GCM key")
+	}
+	return gcm
+}
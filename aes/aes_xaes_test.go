@@ -0,0 +1,91 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package aes
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/hashicorp/go-uuid"
+)
+
+func TestXAES_C2SPVectors(t *testing.T) {
+	// c2sp.org/XAES-256-GCM test vectors
+	testDatas := []struct {
+		key, aad, result string
+	}{
+		{"0101010101010101010101010101010101010101010101010101010101010101", "", "ce546ef63c9cc60765923609b33a9a1974e96e52daf2fcf7075e2271"},
+		{"0303030303030303030303030303030303030303030303030303030303030303", "c2sp.org/XAES-256-GCM", "986ec1832593df5443a179437fd083bf3fdb41abd740a21f71eb769d"},
+	}
+	nonce := []byte("ABCDEFGHIJKLMNOPQRSTUVWX")
+	plaintext := []byte("XAES-256-GCM")
+
+	for _, testData := range testDatas {
+		key, _ := hex.DecodeString(testData.key)
+
+		cipher, err := NewXAES(key)
+		if err != nil {
+			t.Fatalf("Did not expect an error but got %q", err)
+		}
+
+		result := cipher.aead.Seal(nil, nonce, plaintext, []byte(testData.aad))
+		if hex.EncodeToString(result) != testData.result {
+			t.Errorf("Expected %s but got %x", testData.result, result)
+		}
+
+		opened, err := cipher.aead.Open(nil, nonce, result, []byte(testData.aad))
+		if err != nil {
+			t.Errorf("Did not expect a decryption error but got %q", err)
+		}
+		if !bytes.Equal(opened, plaintext) {
+			t.Errorf("Expected %s but got %s", plaintext, opened)
+		}
+	}
+}
+
+func TestXAES_EncryptAndDecrypt(t *testing.T) {
+	keyBytes, _ := uuid.GenerateRandomBytes(32)
+	cipher, _ := NewXAES(keyBytes)
+
+	testDatas := []string{
+		"my secret 1234",
+		"123456789",
+	}
+
+	for _, testData := range testDatas {
+		cipherBytes, nonce, err := cipher.Encrypt([]byte(testData), true)
+		if err != nil {
+			t.Errorf("Did not expect an encryption error but got %q", err)
+		}
+		if len(nonce) != 24 || !bytes.Equal(cipherBytes[:24], nonce) {
+			t.Errorf("Expected the 24 bytes nonce to prefix the cipher bytes")
+		}
+
+		plainBytes, err := cipher.Decrypt(cipherBytes, nil)
+		if err != nil {
+			t.Errorf("Did not expect a decryption error but got %q", err)
+		}
+
+		if testData != string(plainBytes) {
+			t.Errorf("Expected %s but get %s", testData, string(plainBytes))
+		}
+	}
+}
+
+func TestXAES_InvalidKeySize(t *testing.T) {
+	keyBytes, _ := uuid.GenerateRandomBytes(16)
+
+	if _, err := NewXAES(keyBytes); err == nil {
+		t.Error("should be an error for 16 bytes key")
+	}
+}
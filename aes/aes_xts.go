@@ -0,0 +1,148 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package aes
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+)
+
+// XTSCipher is a wrapper of the AES-XTS (IEEE 1619, NIST SP 800-38E) cipher to encrypt fixed size
+// storage sectors, and stores the raw key bytes. It is not authenticated, a modified sector
+// decrypts to random bytes.
+type XTSCipher struct {
+	data     cipher.Block
+	tweak    cipher.Block
+	KeyBytes []byte
+}
+
+// NewXTS constructs a new AES-XTS cipher using the raw key bytes provided, the raw bytes are the
+// data key followed by the tweak key and must be either 32 or 64 bytes, with distinct halves
+func NewXTS(keyBytes []byte) (XTSCipher, error) {
+	if len(keyBytes) != 32 && len(keyBytes) != 64 {
+		return XTSCipher{}, errors.New("AES-XTS key must be either 32 or 64 bytes")
+	}
+	half := len(keyBytes) / 2
+	if subtle.ConstantTimeCompare(keyBytes[:half], keyBytes[half:]) == 1 {
+		return XTSCipher{}, errors.New("AES-XTS data key and tweak key must be different")
+	}
+
+	data, err := aes.NewCipher(keyBytes[:half])
+	if err != nil {
+		return XTSCipher{}, err
+	}
+	tweak, err := aes.NewCipher(keyBytes[half:])
+	if err != nil {
+		return XTSCipher{}, err
+	}
+	return XTSCipher{data, tweak, keyBytes}, nil
+}
+
+// EncryptSector encrypts the sector, the sector number is the tweak so that sectors cannot be
+// moved around. The sector must be at least 16 bytes, a last partial block is handled with
+// ciphertext stealing so the output has the size of the sector.
+func (cipher *XTSCipher) EncryptSector(plainBytes []byte, sectorNum uint64) ([]byte, error) {
+	if err := validateSector(plainBytes); err != nil {
+		return nil, err
+	}
+
+	cipherBytes := make([]byte, len(plainBytes))
+	xtsCrypt(cipher.data, cipher.tweak, cipherBytes, plainBytes, sectorTweak(sectorNum), true)
+	return cipherBytes, nil
+}
+
+// DecryptSector decrypts the sector encrypted with the same sector number
+func (cipher *XTSCipher) DecryptSector(cipherBytes []byte, sectorNum uint64) ([]byte, error) {
+	if err := validateSector(cipherBytes); err != nil {
+		return nil, err
+	}
+
+	plainBytes := make([]byte, len(cipherBytes))
+	xtsCrypt(cipher.data, cipher.tweak, plainBytes, cipherBytes, sectorTweak(sectorNum), false)
+	return plainBytes, nil
+}
+
+func validateSector(sector []byte) error {
+	if len(sector) < aes.BlockSize {
+		return errors.New("sector must be at least 16 bytes")
+	}
+	return nil
+}
+
+// sectorTweak encodes the sector number as the little endian 128 bits tweak of IEEE 1619
+func sectorTweak(sectorNum uint64) [aes.BlockSize]byte {
+	var tweak [aes.BlockSize]byte
+	binary.LittleEndian.PutUint64(tweak[:8], sectorNum)
+	return tweak
+}
+
+// xtsCrypt runs XTS-AES over src, which is at least one block, into dst. With a last partial
+// block the last two blocks are processed with ciphertext stealing: the partial block takes the
+// head of the previous output block and its tail completes the partial input block.
+func xtsCrypt(data cipher.Block, tweakBlock cipher.Block, dst []byte, src []byte, tweak [aes.BlockSize]byte, encrypt bool) {
+	var t [aes.BlockSize]byte
+	tweakBlock.Encrypt(t[:], tweak[:])
+
+	crypt := data.Decrypt
+	if encrypt {
+		crypt = data.Encrypt
+	}
+	cryptBlock := func(out []byte, in []byte, t *[aes.BlockSize]byte) {
+		subtle.XORBytes(out, in, t[:])
+		crypt(out, out)
+		subtle.XORBytes(out, out, t[:])
+	}
+
+	remainder := len(src) % aes.BlockSize
+	full := len(src) - remainder
+	if remainder != 0 {
+		// The last full block is processed with the partial block
+		full -= aes.BlockSize
+	}
+	for i := 0; i < full; i += aes.BlockSize {
+		cryptBlock(dst[i:i+aes.BlockSize], src[i:i+aes.BlockSize], &t)
+		mulAlpha(&t)
+	}
+	if remainder == 0 {
+		return
+	}
+
+	// On decryption the last full block was encrypted with the tweak of the partial block
+	first, second := t, t
+	if encrypt {
+		mulAlpha(&second)
+	} else {
+		mulAlpha(&first)
+	}
+
+	var block [aes.BlockSize]byte
+	cryptBlock(block[:], src[full:full+aes.BlockSize], &first)
+	last := full + aes.BlockSize
+	copy(dst[last:], block[:remainder])
+	copy(block[:remainder], src[last:])
+	cryptBlock(dst[full:last], block[:], &second)
+}
+
+// mulAlpha multiplies the tweak by the primitive element of GF(2^128), the tweak is little endian
+func mulAlpha(t *[aes.BlockSize]byte) {
+	var carry byte
+	for i := range t {
+		next := t[i] >> 7
+		t[i] = t[i]<<1 | carry
+		carry = next
+	}
+	t[0] ^= 0x87 * carry
+}
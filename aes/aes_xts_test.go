@@ -0,0 +1,152 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package aes
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// IEEE 1619-2007, appendix B
+func TestXTS_IEEE1619(t *testing.T) {
+	testData := []struct {
+		key        string
+		sector     uint64
+		plain      string
+		ciphertext string
+	}{
+		{"1111111111111111111111111111111122222222222222222222222222222222", 0x3333333333,
+			strings.Repeat("44", 32), "c454185e6a16936e39334038acef838bfb186fff7480adc4289382ecd6d394f0"},
+		{"fffefdfcfbfaf9f8f7f6f5f4f3f2f1f022222222222222222222222222222222", 0x3333333333,
+			strings.Repeat("44", 32), "af85336b597afc1a900b2eb21ec949d292df4c047e0b21532186a5971a227a89"},
+		// Vectors 15 to 18, the partial last block is handled with ciphertext stealing
+		{"fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0bfbebdbcbbbab9b8b7b6b5b4b3b2b1b0", 0x123456789a,
+			"000102030405060708090a0b0c0d0e0f10", "6c1625db4671522d3d7599601de7ca09ed"},
+		{"fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0bfbebdbcbbbab9b8b7b6b5b4b3b2b1b0", 0x123456789a,
+			"000102030405060708090a0b0c0d0e0f1011", "d069444b7a7e0cab09e24447d24deb1fedbf"},
+		{"fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0bfbebdbcbbbab9b8b7b6b5b4b3b2b1b0", 0x123456789a,
+			"000102030405060708090a0b0c0d0e0f101112", "e5df1351c0544ba1350b3363cd8ef4beedbf9d"},
+		{"fffefdfcfbfaf9f8f7f6f5f4f3f2f1f0bfbebdbcbbbab9b8b7b6b5b4b3b2b1b0", 0x123456789a,
+			"000102030405060708090a0b0c0d0e0f10111213", "9d84c813f719aa2c7be3f66171c7c5c2edbf9dac"},
+	}
+
+	for _, data := range testData {
+		keyBytes, _ := hex.DecodeString(data.key)
+		plainBytes, _ := hex.DecodeString(data.plain)
+
+		cipher, err := NewXTS(keyBytes)
+		if err != nil {
+			t.Fatalf("Did not expect an error but got %q", err)
+		}
+
+		cipherBytes, _ := cipher.EncryptSector(plainBytes, data.sector)
+		if hex.EncodeToString(cipherBytes) != data.ciphertext {
+			t.Errorf("Expected %s but got %x", data.ciphertext, cipherBytes)
+		}
+
+		decrypted, err := cipher.DecryptSector(cipherBytes, data.sector)
+		if err != nil || !bytes.Equal(decrypted, plainBytes) {
+			t.Errorf("Expected %x but got %x (%v)", plainBytes, decrypted, err)
+		}
+	}
+}
+
+// NIST CAVP XTSGenAES128 and XTSGenAES256 with the tweak as a 128 bits hex string, counts 1
+// and 101
+func TestXTS_CAVP(t *testing.T) {
+	testData := []struct {
+		key        string
+		tweak      string
+		plain      string
+		ciphertext string
+	}{
+		{"a1b90cba3f06ac353b2c343876081762090923026e91771815f29dab01932f2f", "4faef7117cda59c66e4b92013e768ad5",
+			"ebabce95b14d3c8d6fb350390790311c", "778ae8b43cb98d5a825081d5be471c63"},
+		{"b7b93f516aef295eff3a29d837cf1f135347e8a21dae616ff5062b2e8d78ce5e", "873edea653b643bd8bcf51403197ed14",
+			"236f8a5b58dd55f6194ed70c4ac1a17f1fe60ec9a6c454d087ccb77d6b638c47",
+			"22e6a3c6379dcf7599b052b5a749c7f78ad8a11b9f1aa9430cf3aef445682e19"},
+		{"1ea661c58d943a0e4801e42f4b0947149e7f9f8e3e68d0c7505210bd311a0e7cd6e13ffdf2418d8d1911c004cda58da3d619b7e2b9141e58318eea392cf41b08",
+			"adf8d92627464ad2f0428e84a9f87564",
+			"2eedea52cd8215e1acc647e810bbc3642e87287f8d2e57e36c0a24fbc12a202e",
+			"cbaad0e2f6cea3f50b37f934d46a9b130b9d54f07e34f36af793e86f73c6d7db"},
+		{"266c336b3b01489f3267f52835fd92f674374b88b4e1ebd2d36a5f457581d9d042c3eef7b0b7e5137b086496b4d9e6ac658d7196a23f23f036172fdb8faee527",
+			"06b209a7a22f486ecbfadb0f3137ba42",
+			"ca7d65ef8d3dfad345b61ccddca1ad81de830b9e86c7b426d76cb7db766852d981c6b21409399d78f42cc0b33a7bbb06",
+			"c73256870cc2f4dd57acc74b5456dbd776912a128bc1f77d72cdebbf270044b7a43ceed29025e1e8be211fa3c3ed002d"},
+	}
+
+	for _, data := range testData {
+		keyBytes, _ := hex.DecodeString(data.key)
+		tweakBytes, _ := hex.DecodeString(data.tweak)
+		plainBytes, _ := hex.DecodeString(data.plain)
+		var tweak [16]byte
+		copy(tweak[:], tweakBytes)
+
+		cipher, err := NewXTS(keyBytes)
+		if err != nil {
+			t.Fatalf("Did not expect an error but got %q", err)
+		}
+
+		cipherBytes := make([]byte, len(plainBytes))
+		xtsCrypt(cipher.data, cipher.tweak, cipherBytes, plainBytes, tweak, true)
+		if hex.EncodeToString(cipherBytes) != data.ciphertext {
+			t.Errorf("Expected %s but got %x", data.ciphertext, cipherBytes)
+		}
+
+		decrypted := make([]byte, len(cipherBytes))
+		xtsCrypt(cipher.data, cipher.tweak, decrypted, cipherBytes, tweak, false)
+		if !bytes.Equal(decrypted, plainBytes) {
+			t.Errorf("Expected %x but got %x", plainBytes, decrypted)
+		}
+	}
+}
+
+func TestXTS_Sectors(t *testing.T) {
+	keyBytes, _ := hex.DecodeString("2718281828459045235360287471352631415926535897932384626433832795")
+	cipher, _ := NewXTS(keyBytes)
+
+	sector := make([]byte, 512)
+	for i := range sector {
+		sector[i] = byte(i)
+	}
+
+	// IEEE 1619-2007 vector 4, only the first 32 bytes are compared
+	cipherBytes, _ := cipher.EncryptSector(sector, 0)
+	if hex.EncodeToString(cipherBytes[:32]) != "27a7479befa1d476489f308cd4cfa6e2a96e4bbe3208ff25287dd3819616e89c" {
+		t.Errorf("Unexpected cipher bytes %x", cipherBytes[:32])
+	}
+
+	otherSector, _ := cipher.EncryptSector(sector, 1)
+	if bytes.Equal(cipherBytes, otherSector) {
+		t.Error("Expected different cipher bytes for different sectors")
+	}
+	if decrypted, _ := cipher.DecryptSector(cipherBytes, 1); bytes.Equal(decrypted, sector) {
+		t.Error("Expected a sector decrypted with the wrong sector number to be garbage")
+	}
+
+	// Sectors which are not a multiple of 16 bytes keep their size
+	cipherBytes, _ = cipher.EncryptSector(sector[:100], 0)
+	if decrypted, err := cipher.DecryptSector(cipherBytes, 0); len(cipherBytes) != 100 || err != nil || !bytes.Equal(decrypted, sector[:100]) {
+		t.Errorf("Expected a 100 bytes sector to round trip but got %x (%v)", decrypted, err)
+	}
+	if _, err := cipher.EncryptSector(sector[:15], 0); err == nil {
+		t.Error("should be an error if the sector is shorter than 16 bytes")
+	}
+	if _, err := NewXTS(make([]byte, 32)); err == nil {
+		t.Error("should be an error if both halves of the key are equal")
+	}
+	if _, err := NewXTS(keyBytes[:24]); err == nil {
+		t.Error("should be an error if the key size is invalid")
+	}
+}
@@ -0,0 +1,55 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+// Package algorithm defines the interfaces shared by the cipher, signature and key types of the
+// other packages, and a registry to construct the symmetric ciphers by name
+package algorithm
+
+// AEAD is a symmetric authenticated cipher generating its own nonces, such as aes.Cipher and
+// chacha.Cipher. The nonce is prefixed to the cipher bytes if prefixNonce is true, and Decrypt
+// expects it prefixed when no nonce is provided.
+type AEAD interface {
+	Encrypt(plainBytes []byte, prefixNonce bool) ([]byte, []byte, error)
+	Decrypt(cipherBytes []byte, nonce []byte) ([]byte, error)
+}
+
+// BlockCipher is a symmetric cipher over whole blocks, such as des.Cipher
+type BlockCipher interface {
+	Encrypt(plainBytes []byte) ([]byte, error)
+	Decrypt(cipherBytes []byte) ([]byte, error)
+}
+
+// Encrypter encrypts messages to the public key it holds
+type Encrypter interface {
+	EncryptMessage(plainBytes []byte) ([]byte, error)
+}
+
+// Decrypter decrypts messages with the private key it holds
+type Decrypter interface {
+	DecryptMessage(cipherBytes []byte) ([]byte, error)
+}
+
+// Signer produces detached signatures with the private key it holds
+type Signer interface {
+	Sign(message []byte) ([]byte, error)
+}
+
+// Verifier checks detached signatures with the public key it holds, a nil error means valid
+type Verifier interface {
+	Verify(message []byte, signature []byte) error
+}
+
+// KeyCheckValueProvider computes and verifies the hex encoded check value of the key it holds
+type KeyCheckValueProvider interface {
+	CheckValue() string
+	VerifyCheckValue(checkValue string) bool
+}
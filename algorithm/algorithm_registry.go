@@ -0,0 +1,117 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package algorithm
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Names of the algorithms registered by the packages of this module, a package registers its
+// algorithms when it is imported
+const (
+	AESGCM            = "aes-gcm"
+	AESGCMSIV         = "aes-gcm-siv"
+	AESGCMCommitting  = "aes-gcm-committing"
+	XAES256GCM        = "xaes-256-gcm"
+	ChaCha20Poly1305  = "chacha20-poly1305"
+	XChaCha20Poly1305 = "xchacha20-poly1305"
+	DES               = "des"
+	TripleDES         = "3des"
+)
+
+// AEADFactory constructs an AEAD from the raw key bytes
+type AEADFactory func(keyBytes []byte) (AEAD, error)
+
+// BlockCipherFactory constructs a BlockCipher from the raw key bytes
+type BlockCipherFactory func(keyBytes []byte) (BlockCipher, error)
+
+var (
+	registryLock sync.RWMutex
+	aeads        = make(map[string]AEADFactory)
+	blockCiphers = make(map[string]BlockCipherFactory)
+)
+
+// RegisterAEAD makes an AEAD available by name, it panics if the name is already registered
+func RegisterAEAD(name string, factory AEADFactory) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	if _, exists := aeads[name]; exists {
+		panic("algorithm: AEAD " + name + " registered twice")
+	}
+	aeads[name] = factory
+}
+
+// RegisterBlockCipher makes a BlockCipher available by name, it panics if the name is already
+// registered
+func RegisterBlockCipher(name string, factory BlockCipherFactory) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+
+	if _, exists := blockCiphers[name]; exists {
+		panic("algorithm: block cipher " + name + " registered twice")
+	}
+	blockCiphers[name] = factory
+}
+
+// NewAEAD constructs the AEAD registered under the name from the raw key bytes
+func NewAEAD(name string, keyBytes []byte) (AEAD, error) {
+	registryLock.RLock()
+	factory, ok := aeads[name]
+	registryLock.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown AEAD %q", name)
+	}
+	return factory(keyBytes)
+}
+
+// NewBlockCipher constructs the BlockCipher registered under the name from the raw key bytes
+func NewBlockCipher(name string, keyBytes []byte) (BlockCipher, error) {
+	registryLock.RLock()
+	factory, ok := blockCiphers[name]
+	registryLock.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown block cipher %q", name)
+	}
+	return factory(keyBytes)
+}
+
+// AEADs returns the sorted names of the registered AEADs
+func AEADs() []string {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	names := make([]string, 0, len(aeads))
+	for name := range aeads {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BlockCiphers returns the sorted names of the registered block ciphers
+func BlockCiphers() []string {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+
+	names := make([]string, 0, len(blockCiphers))
+	for name := range blockCiphers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
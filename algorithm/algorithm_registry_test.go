@@ -0,0 +1,96 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package algorithm_test
+
+import (
+	"reflect"
+	"testing"
+
+	_ "github.com/exohood/exohood-crypto-algorithms/aes"
+	"github.com/exohood/exohood-crypto-algorithms/algorithm"
+	_ "github.com/exohood/exohood-crypto-algorithms/chacha"
+	_ "github.com/exohood/exohood-crypto-algorithms/des"
+	"github.com/hashicorp/go-uuid"
+)
+
+func TestRegistry_AEADs(t *testing.T) {
+	expectedNames := []string{
+		algorithm.AESGCM,
+		algorithm.AESGCMCommitting,
+		algorithm.AESGCMSIV,
+		algorithm.ChaCha20Poly1305,
+		algorithm.XAES256GCM,
+		algorithm.XChaCha20Poly1305,
+	}
+	if !reflect.DeepEqual(algorithm.AEADs(), expectedNames) {
+		t.Errorf("Expected %v but got %v", expectedNames, algorithm.AEADs())
+	}
+
+	keyBytes, _ := uuid.GenerateRandomBytes(32)
+	for _, name := range algorithm.AEADs() {
+		aead, err := algorithm.NewAEAD(name, keyBytes)
+		if err != nil {
+			t.Fatalf("Did not expect an error for %s but got %q", name, err)
+		}
+
+		cipherBytes, _, err := aead.Encrypt([]byte("my secret 1234"), true)
+		if err != nil {
+			t.Errorf("Did not expect an encryption error for %s but got %q", name, err)
+		}
+		plainBytes, err := aead.Decrypt(cipherBytes, nil)
+		if err != nil || string(plainBytes) != "my secret 1234" {
+			t.Errorf("Expected my secret 1234 for %s but got %s (%v)", name, plainBytes, err)
+		}
+	}
+}
+
+func TestRegistry_BlockCiphers(t *testing.T) {
+	expectedNames := []string{algorithm.TripleDES, algorithm.DES}
+	if !reflect.DeepEqual(algorithm.BlockCiphers(), expectedNames) {
+		t.Errorf("Expected %v but got %v", expectedNames, algorithm.BlockCiphers())
+	}
+
+	keyBytes, _ := uuid.GenerateRandomBytes(24)
+	blockCipher, err := algorithm.NewBlockCipher(algorithm.TripleDES, keyBytes)
+	if err != nil {
+		t.Fatalf("Did not expect an error but got %q", err)
+	}
+	if _, ok := blockCipher.(algorithm.KeyCheckValueProvider); !ok {
+		t.Error("Expected the 3DES cipher to provide key check values")
+	}
+
+	cipherBytes, _ := blockCipher.Encrypt([]byte("12345678"))
+	plainBytes, err := blockCipher.Decrypt(cipherBytes)
+	if err != nil || string(plainBytes) != "12345678" {
+		t.Errorf("Expected 12345678 but got %s (%v)", plainBytes, err)
+	}
+
+	if _, err := algorithm.NewBlockCipher(algorithm.DES, keyBytes); err == nil {
+		t.Error("should be an error if the key does not fit the algorithm")
+	}
+}
+
+func TestRegistry_UnknownAndDuplicateNames(t *testing.T) {
+	if _, err := algorithm.NewAEAD("rot13", nil); err == nil {
+		t.Error("should be an error for an unknown AEAD")
+	}
+	if _, err := algorithm.NewBlockCipher("rot13", nil); err == nil {
+		t.Error("should be an error for an unknown block cipher")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("should panic when an algorithm is registered twice")
+		}
+	}()
+	algorithm.RegisterAEAD(algorithm.AESGCM, nil)
+}
@@ -0,0 +1,178 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+// Package cert creates PKCS#10 certificate signing requests and X.509 certificates for the keys
+// generated by the other packages, either self-signed or issued by a small local CA, e.g. to
+// issue test certificates in CI
+package cert
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"math/bits"
+	"net"
+	"net/url"
+)
+
+var (
+	oidExtensionKeyUsage    = asn1.ObjectIdentifier{2, 5, 29, 15}
+	oidExtensionExtKeyUsage = asn1.ObjectIdentifier{2, 5, 29, 37}
+
+	// extKeyUsageOIDs are the extended key usages of RFC 5280 section 4.2.1.12 which can be requested
+	extKeyUsageOIDs = map[x509.ExtKeyUsage]asn1.ObjectIdentifier{
+		x509.ExtKeyUsageAny:             {2, 5, 29, 37, 0},
+		x509.ExtKeyUsageServerAuth:      {1, 3, 6, 1, 5, 5, 7, 3, 1},
+		x509.ExtKeyUsageClientAuth:      {1, 3, 6, 1, 5, 5, 7, 3, 2},
+		x509.ExtKeyUsageCodeSigning:     {1, 3, 6, 1, 5, 5, 7, 3, 3},
+		x509.ExtKeyUsageEmailProtection: {1, 3, 6, 1, 5, 5, 7, 3, 4},
+		x509.ExtKeyUsageTimeStamping:    {1, 3, 6, 1, 5, 5, 7, 3, 8},
+		x509.ExtKeyUsageOCSPSigning:     {1, 3, 6, 1, 5, 5, 7, 3, 9},
+	}
+)
+
+// Request is the subject, the subject alternative names and the usage of a key, for a CSR or a
+// certificate
+type Request struct {
+	Subject        pkix.Name
+	DNSNames       []string
+	EmailAddresses []string
+	IPAddresses    []net.IP
+	URIs           []*url.URL
+	// KeyUsage defaults to digital signature, and key encipherment for RSA keys
+	KeyUsage    x509.KeyUsage
+	ExtKeyUsage []x509.ExtKeyUsage
+}
+
+// CreateCSR creates a PKCS#10 certificate signing request for the key, signed by the key itself.
+// The key usages are requested through the extensions of the CSR.
+func CreateCSR(key crypto.Signer, req Request) (*x509.CertificateRequest, error) {
+	extensions, err := usageExtensions(req.keyUsage(key.Public()), req.ExtKeyUsage)
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:         req.Subject,
+		DNSNames:        req.DNSNames,
+		EmailAddresses:  req.EmailAddresses,
+		IPAddresses:     req.IPAddresses,
+		URIs:            req.URIs,
+		ExtraExtensions: extensions,
+	}, key)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificateRequest(der)
+}
+
+// RequestFromCSR returns the subject, the names and the key usages requested by the CSR, after
+// checking its signature
+func RequestFromCSR(csr *x509.CertificateRequest) (Request, error) {
+	if err := csr.CheckSignature(); err != nil {
+		return Request{}, fmt.Errorf("invalid CSR signature: %w", err)
+	}
+
+	req := Request{
+		Subject:        csr.Subject,
+		DNSNames:       csr.DNSNames,
+		EmailAddresses: csr.EmailAddresses,
+		IPAddresses:    csr.IPAddresses,
+		URIs:           csr.URIs,
+	}
+	for _, extension := range csr.Extensions {
+		switch {
+		case extension.Id.Equal(oidExtensionKeyUsage):
+			var usage asn1.BitString
+			if _, err := asn1.Unmarshal(extension.Value, &usage); err != nil {
+				return Request{}, fmt.Errorf("invalid CSR key usage: %w", err)
+			}
+			for i := 0; i < 9; i++ {
+				if usage.At(i) != 0 {
+					req.KeyUsage |= 1 << i
+				}
+			}
+		case extension.Id.Equal(oidExtensionExtKeyUsage):
+			var oids []asn1.ObjectIdentifier
+			if _, err := asn1.Unmarshal(extension.Value, &oids); err != nil {
+				return Request{}, fmt.Errorf("invalid CSR extended key usage: %w", err)
+			}
+			for _, oid := range oids {
+				usage, ok := extKeyUsage(oid)
+				if !ok {
+					return Request{}, fmt.Errorf("unsupported extended key usage %s", oid)
+				}
+				req.ExtKeyUsage = append(req.ExtKeyUsage, usage)
+			}
+		}
+	}
+	return req, nil
+}
+
+func (req Request) keyUsage(publicKey crypto.PublicKey) x509.KeyUsage {
+	if req.KeyUsage != 0 {
+		return req.KeyUsage
+	}
+	if _, ok := publicKey.(*rsa.PublicKey); ok {
+		return x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+	}
+	return x509.KeyUsageDigitalSignature
+}
+
+// usageExtensions encodes the key usage and extended key usage extensions of RFC 5280, which
+// crypto/x509 only writes in certificates
+func usageExtensions(keyUsage x509.KeyUsage, extKeyUsage []x509.ExtKeyUsage) ([]pkix.Extension, error) {
+	// The bit 0 of the key usage is the most significant bit of the first byte
+	usage := []byte{bits.Reverse8(byte(keyUsage)), bits.Reverse8(byte(keyUsage >> 8))}
+	if usage[1] == 0 {
+		usage = usage[:1]
+	}
+	bitLength := len(usage) * 8
+	if last := usage[len(usage)-1]; last != 0 {
+		bitLength -= bits.TrailingZeros8(last)
+	}
+	value, err := asn1.Marshal(asn1.BitString{Bytes: usage, BitLength: bitLength})
+	if err != nil {
+		return nil, err
+	}
+	extensions := []pkix.Extension{{Id: oidExtensionKeyUsage, Critical: true, Value: value}}
+
+	if len(extKeyUsage) > 0 {
+		oids := make([]asn1.ObjectIdentifier, len(extKeyUsage))
+		for i, usage := range extKeyUsage {
+			oid, ok := extKeyUsageOIDs[usage]
+			if !ok {
+				return nil, fmt.Errorf("unsupported extended key usage %d", usage)
+			}
+			oids[i] = oid
+		}
+		value, err := asn1.Marshal(oids)
+		if err != nil {
+			return nil, err
+		}
+		extensions = append(extensions, pkix.Extension{Id: oidExtensionExtKeyUsage, Value: value})
+	}
+	return extensions, nil
+}
+
+func extKeyUsage(oid asn1.ObjectIdentifier) (x509.ExtKeyUsage, bool) {
+	for usage, usageOID := range extKeyUsageOIDs {
+		if usageOID.Equal(oid) {
+			return usage, true
+		}
+	}
+	return 0, false
+}
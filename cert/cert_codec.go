@@ -0,0 +1,210 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package cert
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+const (
+	pemTypeCertificate = "CERTIFICATE"
+	pemTypeCSR         = "CERTIFICATE REQUEST"
+	pemTypeCRL         = "X509 CRL"
+	pemTypePrivateKey  = "PRIVATE KEY"
+	pemTypeRSAPrivate  = "RSA PRIVATE KEY"
+	pemTypeECPrivate   = "EC PRIVATE KEY"
+
+	certificateFileMode = 0644
+	privateKeyFileMode  = 0600
+)
+
+// MarshalCertificatePEM encodes the certificates as consecutive CERTIFICATE PEM blocks, e.g. a leaf
+// followed by its chain
+func MarshalCertificatePEM(certificates ...*x509.Certificate) []byte {
+	var out []byte
+	for _, certificate := range certificates {
+		out = append(out, pem.EncodeToMemory(&pem.Block{Type: pemTypeCertificate, Bytes: certificate.Raw})...)
+	}
+	return out
+}
+
+// MarshalCSRPEM encodes the CSR as a CERTIFICATE REQUEST PEM block
+func MarshalCSRPEM(csr *x509.CertificateRequest) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: pemTypeCSR, Bytes: csr.Raw})
+}
+
+// MarshalCRLPEM encodes the CRL as an X509 CRL PEM block
+func MarshalCRLPEM(crl *x509.RevocationList) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: pemTypeCRL, Bytes: crl.Raw})
+}
+
+// ParseCertificates decodes every CERTIFICATE block of a PEM input, or a single DER certificate
+func ParseCertificates(data []byte) ([]*x509.Certificate, error) {
+	if !isPEM(data) {
+		certificate, err := x509.ParseCertificate(data)
+		if err != nil {
+			return nil, err
+		}
+		return []*x509.Certificate{certificate}, nil
+	}
+
+	var certificates []*x509.Certificate
+	for rest := bytes.TrimSpace(data); len(rest) > 0; rest = bytes.TrimSpace(rest) {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			return nil, errors.New("invalid PEM encoding")
+		}
+		if block.Type != pemTypeCertificate {
+			return nil, fmt.Errorf("unexpected PEM block %q for a certificate", block.Type)
+		}
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certificates = append(certificates, certificate)
+	}
+	if len(certificates) == 0 {
+		return nil, errors.New("no certificate found")
+	}
+	return certificates, nil
+}
+
+// ParseCertificate decodes a PEM or DER certificate, the input must hold exactly one
+func ParseCertificate(data []byte) (*x509.Certificate, error) {
+	certificates, err := ParseCertificates(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(certificates) != 1 {
+		return nil, fmt.Errorf("expected one certificate but found %d", len(certificates))
+	}
+	return certificates[0], nil
+}
+
+// ParseCSR decodes a PEM or DER CSR and checks its signature
+func ParseCSR(data []byte) (*x509.CertificateRequest, error) {
+	der, err := decodePEM(data, pemTypeCSR)
+	if err != nil {
+		return nil, err
+	}
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		return nil, err
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("invalid CSR signature: %w", err)
+	}
+	return csr, nil
+}
+
+// ParseCRL decodes a PEM or DER CRL, its signature is checked by the TrustStore against its issuer
+func ParseCRL(data []byte) (*x509.RevocationList, error) {
+	der, err := decodePEM(data, pemTypeCRL)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseRevocationList(der)
+}
+
+// LoadCertificates reads a PEM or DER certificate file, see ParseCertificates
+func LoadCertificates(path string) ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCertificates(data)
+}
+
+// StoreCertificates writes the certificates as a PEM file
+func StoreCertificates(path string, certificates ...*x509.Certificate) error {
+	return os.WriteFile(path, MarshalCertificatePEM(certificates...), certificateFileMode)
+}
+
+// LoadCA reads a CA certificate and its PKCS#8, PKCS#1 or SEC 1 private key from PEM files
+func LoadCA(certificatePath string, keyPath string) (*CA, error) {
+	certificates, err := LoadCertificates(certificatePath)
+	if err != nil {
+		return nil, err
+	}
+	if len(certificates) != 1 || !certificates[0].IsCA {
+		return nil, errors.New("CA file must hold exactly one CA certificate")
+	}
+
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid PEM encoding")
+	}
+	var key interface{}
+	switch block.Type {
+	case pemTypePrivateKey:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case pemTypeRSAPrivate:
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case pemTypeECPrivate:
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unexpected PEM block %q for a private key", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := key.(crypto.Signer)
+	if !ok || !certificates[0].PublicKey.(interface{ Equal(crypto.PublicKey) bool }).Equal(signer.Public()) {
+		return nil, errors.New("CA private key does not match its certificate")
+	}
+	return &CA{certificates[0], signer}, nil
+}
+
+// Store writes the CA certificate as PEM and its private key as a PKCS#8 PEM file only readable by
+// its owner
+func (ca *CA) Store(certificatePath string, keyPath string) error {
+	der, err := x509.MarshalPKCS8PrivateKey(ca.Key)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: pemTypePrivateKey, Bytes: der}), privateKeyFileMode); err != nil {
+		return err
+	}
+	return StoreCertificates(certificatePath, ca.Certificate)
+}
+
+func isPEM(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("-----BEGIN "))
+}
+
+// decodePEM returns the bytes of the first PEM block, which must have the type, or the input
+// itself if it is not PEM encoded
+func decodePEM(data []byte, blockType string) ([]byte, error) {
+	if !isPEM(data) {
+		return data, nil
+	}
+	block, _ := pem.Decode(bytes.TrimSpace(data))
+	if block == nil {
+		return nil, errors.New("invalid PEM encoding")
+	}
+	if block.Type != blockType {
+		return nil, fmt.Errorf("unexpected PEM block %q, expected %q", block.Type, blockType)
+	}
+	return block.Bytes, nil
+}
@@ -0,0 +1,76 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package cert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509/pkix"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCertificatePEM(t *testing.T) {
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ca, _ := NewCA(caKey, pkix.Name{CommonName: "CI Root"}, 2*time.Hour)
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	leaf, _ := ca.Issue(key.Public(), Request{Subject: pkix.Name{CommonName: "leaf"}}, time.Hour)
+
+	chain, err := ParseCertificates(MarshalCertificatePEM(leaf, ca.Certificate))
+	if err != nil || len(chain) != 2 || !chain[0].Equal(leaf) || !chain[1].Equal(ca.Certificate) {
+		t.Fatal("Expected the chain to round trip", err)
+	}
+	if _, err := ParseCertificate(MarshalCertificatePEM(leaf, ca.Certificate)); err == nil {
+		t.Fatal("Expected an error for a chain")
+	}
+	if parsed, err := ParseCertificate(leaf.Raw); err != nil || !parsed.Equal(leaf) {
+		t.Fatal("Expected the DER certificate to parse", err)
+	}
+
+	csr, _ := CreateCSR(key, Request{Subject: pkix.Name{CommonName: "leaf"}})
+	if _, err := ParseCertificates(MarshalCSRPEM(csr)); err == nil {
+		t.Fatal("Expected an error for a CSR")
+	}
+	if _, err := ParseCSR(MarshalCertificatePEM(leaf)); err == nil {
+		t.Fatal("Expected an error for a certificate")
+	}
+}
+
+func TestStoreLoadCA(t *testing.T) {
+	dir := t.TempDir()
+	certificatePath, keyPath := filepath.Join(dir, "ca.pem"), filepath.Join(dir, "ca.key")
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ca, _ := NewCA(caKey, pkix.Name{CommonName: "CI Root"}, 2*time.Hour)
+
+	if err := ca.Store(certificatePath, keyPath); err != nil {
+		t.Fatal("Did not expect an error", err)
+	}
+	if info, _ := os.Stat(keyPath); info.Mode().Perm() != 0600 {
+		t.Fatalf("Expected the key file mode 0600 but got %o", info.Mode().Perm())
+	}
+
+	loaded, err := LoadCA(certificatePath, keyPath)
+	if err != nil || !loaded.Certificate.Equal(ca.Certificate) || !caKey.Equal(loaded.Key) {
+		t.Fatal("Expected the CA to round trip", err)
+	}
+
+	other, _ := NewCA(caKey, pkix.Name{CommonName: "Other"}, time.Hour)
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	other.Key = otherKey
+	_ = other.Store(certificatePath, keyPath)
+	if _, err := LoadCA(certificatePath, keyPath); err == nil {
+		t.Fatal("Expected an error for a key which does not match the certificate")
+	}
+}
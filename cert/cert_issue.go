@@ -0,0 +1,153 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package cert
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"time"
+)
+
+const (
+	// DefaultValidity is the validity used when none is given
+	DefaultValidity = 365 * 24 * time.Hour
+	// backdate moves NotBefore into the past so that peers with a late clock accept new certificates
+	backdate = 5 * time.Minute
+	// serialBits keeps the random serial numbers positive and below the 20 bytes of RFC 5280
+	serialBits = 127
+)
+
+// CA is a local certificate authority, holding its certificate and its signing key
+type CA struct {
+	Certificate *x509.Certificate
+	Key         crypto.Signer
+}
+
+// CreateSelfSigned issues a certificate for the key signed by the key itself, valid from now for
+// the validity
+func CreateSelfSigned(key crypto.Signer, req Request, validity time.Duration) (*x509.Certificate, error) {
+	template, err := newTemplate(key.Public(), req, validity)
+	if err != nil {
+		return nil, err
+	}
+	return createCertificate(template, template, key.Public(), key)
+}
+
+// NewCA creates a local CA with a self-signed root certificate for the key, it can only issue end
+// entity certificates
+func NewCA(key crypto.Signer, subject pkix.Name, validity time.Duration) (*CA, error) {
+	template, err := newTemplate(key.Public(), Request{Subject: subject, KeyUsage: x509.KeyUsageCertSign | x509.KeyUsageCRLSign}, validity)
+	if err != nil {
+		return nil, err
+	}
+	template.IsCA = true
+	template.MaxPathLenZero = true
+
+	certificate, err := createCertificate(template, template, key.Public(), key)
+	if err != nil {
+		return nil, err
+	}
+	return &CA{certificate, key}, nil
+}
+
+// Issue issues an end entity certificate for the public key, valid from now for the validity but
+// not beyond the CA certificate
+func (ca *CA) Issue(publicKey crypto.PublicKey, req Request, validity time.Duration) (*x509.Certificate, error) {
+	if req.KeyUsage&(x509.KeyUsageCertSign|x509.KeyUsageCRLSign) != 0 {
+		return nil, errors.New("the CA can only issue end entity certificates")
+	}
+	template, err := newTemplate(publicKey, req, validity)
+	if err != nil {
+		return nil, err
+	}
+	if template.NotAfter.After(ca.Certificate.NotAfter) {
+		return nil, errors.New("certificate validity exceeds the validity of the CA")
+	}
+	return createCertificate(template, ca.Certificate, publicKey, ca.Key)
+}
+
+// SignCSR issues a certificate for the key of the CSR with the subject, the names and the key
+// usages it requests, see Issue
+func (ca *CA) SignCSR(csr *x509.CertificateRequest, validity time.Duration) (*x509.Certificate, error) {
+	req, err := RequestFromCSR(csr)
+	if err != nil {
+		return nil, err
+	}
+	return ca.Issue(csr.PublicKey, req, validity)
+}
+
+// CreateCRL issues a CRL revoking the serial numbers, valid from now for the validity. The number
+// must increase with every CRL of the CA.
+func (ca *CA) CreateCRL(number int64, validity time.Duration, serialNumbers ...*big.Int) (*x509.RevocationList, error) {
+	if validity <= 0 {
+		return nil, errors.New("validity must be positive")
+	}
+
+	now := time.Now()
+	entries := make([]x509.RevocationListEntry, len(serialNumbers))
+	for i, serialNumber := range serialNumbers {
+		entries[i] = x509.RevocationListEntry{SerialNumber: serialNumber, RevocationTime: now}
+	}
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:                    big.NewInt(number),
+		ThisUpdate:                now.Add(-backdate),
+		NextUpdate:                now.Add(validity),
+		RevokedCertificateEntries: entries,
+	}, ca.Certificate, ca.Key)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseRevocationList(der)
+}
+
+// newTemplate fills the fields shared by every certificate
+func newTemplate(publicKey crypto.PublicKey, req Request, validity time.Duration) (*x509.Certificate, error) {
+	if validity == 0 {
+		validity = DefaultValidity
+	}
+	if validity < 0 {
+		return nil, errors.New("validity must be positive")
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), serialBits))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+
+	return &x509.Certificate{
+		SerialNumber:          serialNumber.Add(serialNumber, big.NewInt(1)),
+		Subject:               req.Subject,
+		DNSNames:              req.DNSNames,
+		EmailAddresses:        req.EmailAddresses,
+		IPAddresses:           req.IPAddresses,
+		URIs:                  req.URIs,
+		NotBefore:             now.Add(-backdate),
+		NotAfter:              now.Add(validity),
+		KeyUsage:              req.keyUsage(publicKey),
+		ExtKeyUsage:           req.ExtKeyUsage,
+		BasicConstraintsValid: true,
+	}, nil
+}
+
+func createCertificate(template, parent *x509.Certificate, publicKey crypto.PublicKey, signer crypto.Signer) (*x509.Certificate, error) {
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, publicKey, signer)
+	if err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(der)
+}
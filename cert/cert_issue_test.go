@@ -0,0 +1,89 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package cert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+	"time"
+)
+
+func TestCreateSelfSigned(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	req := Request{Subject: pkix.Name{CommonName: "partner.example.com"}, DNSNames: []string{"partner.example.com"}}
+
+	certificate, err := CreateSelfSigned(key, req, 24*time.Hour)
+	if err != nil {
+		t.Fatal("Did not expect an error", err)
+	}
+	if err := certificate.CheckSignature(certificate.SignatureAlgorithm, certificate.RawTBSCertificate, certificate.Signature); err != nil {
+		t.Fatal("Expected the certificate to be self-signed", err)
+	}
+	if certificate.IsCA || certificate.KeyUsage != x509.KeyUsageDigitalSignature|x509.KeyUsageKeyEncipherment {
+		t.Fatal("Expected an end entity certificate with the default RSA key usage")
+	}
+	if validity := certificate.NotAfter.Sub(certificate.NotBefore); validity != 24*time.Hour+backdate {
+		t.Fatalf("Expected a validity of 24 hours but got %s", validity)
+	}
+	if certificate.SerialNumber.Sign() <= 0 || len(certificate.SerialNumber.Bytes()) > 20 {
+		t.Fatal("Expected a positive serial number of at most 20 bytes")
+	}
+
+	if _, err := CreateSelfSigned(key, req, -time.Hour); err == nil {
+		t.Fatal("Expected an error for a negative validity")
+	}
+}
+
+func TestCAIssue(t *testing.T) {
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ca, err := NewCA(caKey, pkix.Name{CommonName: "CI Root"}, 48*time.Hour)
+	if err != nil {
+		t.Fatal("Did not expect an error", err)
+	}
+	if !ca.Certificate.IsCA || ca.Certificate.KeyUsage != x509.KeyUsageCertSign|x509.KeyUsageCRLSign {
+		t.Fatal("Expected a CA certificate")
+	}
+
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	csr, _ := CreateCSR(key, Request{
+		Subject:     pkix.Name{CommonName: "plugin"},
+		DNSNames:    []string{"plugin.ci"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	})
+	certificate, err := ca.SignCSR(csr, time.Hour)
+	if err != nil {
+		t.Fatal("Did not expect an error", err)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Certificate)
+	_, err = certificate.Verify(x509.VerifyOptions{DNSName: "plugin.ci", Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}})
+	if err != nil {
+		t.Fatal("Expected the certificate to chain to the CA", err)
+	}
+	if !key.PublicKey.Equal(certificate.PublicKey) || certificate.IsCA {
+		t.Fatal("Expected an end entity certificate for the CSR key")
+	}
+
+	// The CA can only issue end entity certificates
+	if _, err := ca.Issue(key.Public(), Request{KeyUsage: x509.KeyUsageCertSign}, time.Hour); err == nil {
+		t.Fatal("Expected an error for a certificate signing key usage")
+	}
+	if _, err := ca.Issue(key.Public(), Request{}, 72*time.Hour); err == nil {
+		t.Fatal("Expected an error for a validity beyond the CA")
+	}
+}
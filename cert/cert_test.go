@@ -0,0 +1,100 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package cert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestCreateCSR(t *testing.T) {
+	key, _ := rsa.GenerateKey(rand.Reader, 2048)
+	req := Request{
+		Subject:     pkix.Name{CommonName: "plugin.example.com", Organization: []string{"Exohood"}},
+		DNSNames:    []string{"plugin.example.com", "plugin.internal"},
+		IPAddresses: []net.IP{net.ParseIP("10.0.0.1").To4()},
+		KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageDataEncipherment,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	csr, err := CreateCSR(key, req)
+	if err != nil {
+		t.Fatal("Did not expect an error", err)
+	}
+	parsed, err := ParseCSR(MarshalCSRPEM(csr))
+	if err != nil {
+		t.Fatal("Expected the PEM CSR to parse", err)
+	}
+	if !key.PublicKey.Equal(parsed.PublicKey) {
+		t.Fatal("Expected the CSR to hold the public key")
+	}
+
+	requested, err := RequestFromCSR(parsed)
+	if err != nil {
+		t.Fatal("Did not expect an error", err)
+	}
+	if requested.Subject.CommonName != req.Subject.CommonName || !reflect.DeepEqual(requested.DNSNames, req.DNSNames) ||
+		!reflect.DeepEqual(requested.IPAddresses, req.IPAddresses) || requested.KeyUsage != req.KeyUsage ||
+		!reflect.DeepEqual(requested.ExtKeyUsage, req.ExtKeyUsage) {
+		t.Fatalf("Expected the CSR to request %+v but got %+v", req, requested)
+	}
+}
+
+func TestCSRDefaultKeyUsage(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	for _, tc := range []struct {
+		key      interface{}
+		expected x509.KeyUsage
+	}{
+		{rsaKey, x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment},
+		{ecKey, x509.KeyUsageDigitalSignature},
+	} {
+		var csr *x509.CertificateRequest
+		var err error
+		switch key := tc.key.(type) {
+		case *rsa.PrivateKey:
+			csr, err = CreateCSR(key, Request{Subject: pkix.Name{CommonName: "default"}})
+		case *ecdsa.PrivateKey:
+			csr, err = CreateCSR(key, Request{Subject: pkix.Name{CommonName: "default"}})
+		}
+		if err != nil {
+			t.Fatal("Did not expect an error", err)
+		}
+		requested, _ := RequestFromCSR(csr)
+		if requested.KeyUsage != tc.expected {
+			t.Errorf("Expected the key usage %d for %T but got %d", tc.expected, tc.key, requested.KeyUsage)
+		}
+	}
+}
+
+func TestCSRErrors(t *testing.T) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if _, err := CreateCSR(key, Request{ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageNetscapeServerGatedCrypto}}); err == nil {
+		t.Fatal("Expected an error for an unsupported extended key usage")
+	}
+
+	csr, _ := CreateCSR(key, Request{Subject: pkix.Name{CommonName: "tampered"}})
+	raw := append([]byte(nil), csr.Raw...)
+	raw[len(raw)-1] ^= 1
+	if _, err := ParseCSR(raw); err == nil {
+		t.Fatal("Expected an error for an invalid signature")
+	}
+}
@@ -0,0 +1,195 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package cert
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+var (
+	// ErrRevoked is returned when a certificate of the chain is listed in the CRL of its issuer
+	ErrRevoked = errors.New("certificate is revoked")
+	// ErrKeyUsage is returned when the certificate does not allow the required key usage
+	ErrKeyUsage = errors.New("certificate does not allow the required key usage")
+	// ErrStaleCRL is returned when the store only holds CRLs of the issuer which are not current
+	ErrStaleCRL = errors.New("CRL of the issuer is not current")
+)
+
+// TrustStore verifies partner certificates against the configured trust anchors, with the
+// intermediates and the CRLs loaded from local files
+type TrustStore struct {
+	roots         *x509.CertPool
+	intermediates *x509.CertPool
+	crls          []*x509.RevocationList
+}
+
+// VerifyOptions configures the checks of a certificate beyond its chain and its validity period
+type VerifyOptions struct {
+	// KeyUsage lists the key usage bits the certificate must allow, e.g. key encipherment
+	KeyUsage x509.KeyUsage
+	// ExtKeyUsage lists the accepted extended key usages, any is accepted when not set
+	ExtKeyUsage []x509.ExtKeyUsage
+	// DNSName is checked against the subject alternative names when set
+	DNSName string
+	// RequireCRL fails the verification when the issuer of a certificate has no current CRL in the
+	// store, a stale CRL of the issuer always fails it
+	RequireCRL bool
+	// CurrentTime is the time of the verification, the current time is used when not set
+	CurrentTime time.Time
+}
+
+// NewTrustStore creates a trust store whose trust anchors are the certificates
+func NewTrustStore(anchors ...*x509.Certificate) (*TrustStore, error) {
+	if len(anchors) == 0 {
+		return nil, errors.New("at least one trust anchor is required")
+	}
+	store := &TrustStore{roots: x509.NewCertPool(), intermediates: x509.NewCertPool()}
+	for _, anchor := range anchors {
+		store.roots.AddCert(anchor)
+	}
+	return store, nil
+}
+
+// LoadTrustStore creates a trust store from PEM or DER files of trust anchors
+func LoadTrustStore(anchorPaths ...string) (*TrustStore, error) {
+	var anchors []*x509.Certificate
+	for _, path := range anchorPaths {
+		certificates, err := LoadCertificates(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load trust anchors from %s: %w", path, err)
+		}
+		anchors = append(anchors, certificates...)
+	}
+	return NewTrustStore(anchors...)
+}
+
+// AddIntermediates adds certificates which can complete the chains of partner certificates, they
+// are not trusted by themselves
+func (s *TrustStore) AddIntermediates(certificates ...*x509.Certificate) {
+	for _, certificate := range certificates {
+		s.intermediates.AddCert(certificate)
+	}
+}
+
+// AddCRL adds a CRL, its signature is checked against its issuer during the verification
+func (s *TrustStore) AddCRL(crl *x509.RevocationList) {
+	s.crls = append(s.crls, crl)
+}
+
+// LoadCRL reads a PEM or DER CRL file and adds it, see AddCRL
+func (s *TrustStore) LoadCRL(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	crl, err := ParseCRL(data)
+	if err != nil {
+		return err
+	}
+	s.AddCRL(crl)
+	return nil
+}
+
+// Verify checks that the certificate chains to a trust anchor, with the given and the stored
+// intermediates, that every certificate is within its validity period and not revoked, and that
+// the certificate allows the key usages. It returns the verified chain, from the certificate to
+// the trust anchor.
+func (s *TrustStore) Verify(certificate *x509.Certificate, intermediates []*x509.Certificate, opts VerifyOptions) ([]*x509.Certificate, error) {
+	now := opts.CurrentTime
+	if now.IsZero() {
+		now = time.Now()
+	}
+	extKeyUsage := opts.ExtKeyUsage
+	if len(extKeyUsage) == 0 {
+		extKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageAny}
+	}
+
+	pool := s.intermediates.Clone()
+	for _, intermediate := range intermediates {
+		pool.AddCert(intermediate)
+	}
+	chains, err := certificate.Verify(x509.VerifyOptions{
+		DNSName:       opts.DNSName,
+		Roots:         s.roots,
+		Intermediates: pool,
+		CurrentTime:   now,
+		KeyUsages:     extKeyUsage,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if certificate.KeyUsage&opts.KeyUsage != opts.KeyUsage {
+		return nil, ErrKeyUsage
+	}
+
+	// Any chain without a revoked certificate is accepted, the error of the last one is returned
+	for _, chain := range chains {
+		if err = s.checkRevocation(chain, now, opts.RequireCRL); err == nil {
+			return chain, nil
+		}
+	}
+	return nil, err
+}
+
+// VerifyAndExtractPublicKey parses a PEM or DER certificate, optionally followed by its
+// intermediates, verifies it as per Verify and returns its public key
+func (s *TrustStore) VerifyAndExtractPublicKey(data []byte, opts VerifyOptions) (crypto.PublicKey, error) {
+	certificates, err := ParseCertificates(data)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.Verify(certificates[0], certificates[1:], opts); err != nil {
+		return nil, err
+	}
+	return certificates[0].PublicKey, nil
+}
+
+// checkRevocation looks up every certificate of the chain but the trust anchor in the CRLs signed
+// by its issuer. A certificate listed in any of them is revoked, and the verification fails closed
+// when the CRLs of the issuer are all past their next update.
+func (s *TrustStore) checkRevocation(chain []*x509.Certificate, now time.Time, requireCRL bool) error {
+	for i := 0; i < len(chain)-1; i++ {
+		certificate, issuer := chain[i], chain[i+1]
+
+		current, stale := false, false
+		for _, crl := range s.crls {
+			if !bytes.Equal(crl.RawIssuer, issuer.RawSubject) || crl.CheckSignatureFrom(issuer) != nil {
+				continue
+			}
+			for _, entry := range crl.RevokedCertificateEntries {
+				if entry.SerialNumber.Cmp(certificate.SerialNumber) == 0 {
+					return fmt.Errorf("%w: serial number %s of %q", ErrRevoked, certificate.SerialNumber, certificate.Subject)
+				}
+			}
+
+			if now.Before(crl.ThisUpdate) || (!crl.NextUpdate.IsZero() && now.After(crl.NextUpdate)) {
+				stale = true
+			} else {
+				current = true
+			}
+		}
+		if !current && stale {
+			return fmt.Errorf("%w: %q", ErrStaleCRL, issuer.Subject)
+		}
+		if !current && requireCRL {
+			return fmt.Errorf("no current CRL for the issuer %q", issuer.Subject)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package cert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestCA(t *testing.T, name string) *CA {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ca, err := NewCA(key, pkix.Name{CommonName: name}, 4*time.Hour)
+	if err != nil {
+		t.Fatal("Failed to create the CA", err)
+	}
+	return ca
+}
+
+func TestVerifyAndExtractPublicKey(t *testing.T) {
+	ca := newTestCA(t, "Partner Root")
+	store, _ := NewTrustStore(ca.Certificate)
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	leaf, _ := ca.Issue(key.Public(), Request{
+		Subject:     pkix.Name{CommonName: "partner"},
+		DNSNames:    []string{"partner.example.com"},
+		KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyAgreement,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, time.Hour)
+
+	pub, err := store.VerifyAndExtractPublicKey(MarshalCertificatePEM(leaf), VerifyOptions{KeyUsage: x509.KeyUsageKeyAgreement, DNSName: "partner.example.com"})
+	if err != nil || !key.PublicKey.Equal(pub) {
+		t.Fatal("Expected the verified public key", err)
+	}
+
+	for name, opts := range map[string]VerifyOptions{
+		"expired":       {CurrentTime: time.Now().Add(2 * time.Hour)},
+		"not yet valid": {CurrentTime: time.Now().Add(-time.Hour)},
+		"key usage":     {KeyUsage: x509.KeyUsageKeyEncipherment},
+		"ext key usage": {ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}},
+		"DNS name":      {DNSName: "other.example.com"},
+		"no CRL":        {RequireCRL: true},
+	} {
+		if _, err := store.VerifyAndExtractPublicKey(leaf.Raw, opts); err == nil {
+			t.Errorf("Expected an error for the %s check", name)
+		}
+	}
+	if _, err := store.Verify(leaf, nil, VerifyOptions{KeyUsage: x509.KeyUsageKeyEncipherment}); !errors.Is(err, ErrKeyUsage) {
+		t.Error("Expected ErrKeyUsage but got", err)
+	}
+
+	// A certificate of another CA with the same name is not trusted
+	impostor := newTestCA(t, "Partner Root")
+	forged, _ := impostor.Issue(key.Public(), Request{Subject: pkix.Name{CommonName: "partner"}}, time.Hour)
+	if _, err := store.VerifyAndExtractPublicKey(MarshalCertificatePEM(forged, impostor.Certificate), VerifyOptions{}); err == nil {
+		t.Error("Expected an error for a certificate of an untrusted CA")
+	}
+}
+
+func TestVerifyRevocation(t *testing.T) {
+	ca := newTestCA(t, "Partner Root")
+	store, _ := NewTrustStore(ca.Certificate)
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	leaf, _ := ca.Issue(key.Public(), Request{Subject: pkix.Name{CommonName: "partner"}}, 3*time.Hour)
+	other, _ := ca.Issue(key.Public(), Request{Subject: pkix.Name{CommonName: "other"}}, 3*time.Hour)
+
+	// A CRL of another CA with the same name is ignored
+	impostor := newTestCA(t, "Partner Root")
+	forged, _ := impostor.CreateCRL(1, time.Hour, other.SerialNumber)
+	store.AddCRL(forged)
+	if _, err := store.Verify(other, nil, VerifyOptions{}); err != nil {
+		t.Fatal("Expected the CRL of another CA to be ignored", err)
+	}
+	if _, err := store.Verify(other, nil, VerifyOptions{RequireCRL: true}); err == nil {
+		t.Fatal("Expected an error without a CRL of the issuer")
+	}
+
+	crl, _ := ca.CreateCRL(2, time.Hour, leaf.SerialNumber)
+	path := filepath.Join(t.TempDir(), "partner.crl")
+	_ = os.WriteFile(path, MarshalCRLPEM(crl), 0644)
+	if err := store.LoadCRL(path); err != nil {
+		t.Fatal("Did not expect an error", err)
+	}
+
+	if _, err := store.Verify(leaf, nil, VerifyOptions{}); !errors.Is(err, ErrRevoked) {
+		t.Fatal("Expected ErrRevoked but got", err)
+	}
+	if _, err := store.Verify(other, nil, VerifyOptions{RequireCRL: true}); err != nil {
+		t.Fatal("Expected a certificate absent from the CRL to verify", err)
+	}
+
+	// Once the CRL is stale the verification fails, the listed certificates stay revoked
+	later := VerifyOptions{CurrentTime: time.Now().Add(2 * time.Hour)}
+	if _, err := store.Verify(leaf, nil, later); !errors.Is(err, ErrRevoked) {
+		t.Fatal("Expected ErrRevoked with an expired CRL but got", err)
+	}
+	if _, err := store.Verify(other, nil, later); !errors.Is(err, ErrStaleCRL) {
+		t.Fatal("Expected ErrStaleCRL but got", err)
+	}
+
+	// A current CRL next to the stale one is used
+	renewed, _ := ca.CreateCRL(3, 3*time.Hour, leaf.SerialNumber)
+	store.AddCRL(renewed)
+	later.RequireCRL = true
+	if _, err := store.Verify(other, nil, later); err != nil {
+		t.Fatal("Expected the current CRL to be used", err)
+	}
+}
+
+func TestLoadTrustStore(t *testing.T) {
+	ca := newTestCA(t, "Partner Root")
+	path := filepath.Join(t.TempDir(), "anchors.pem")
+	_ = StoreCertificates(path, ca.Certificate, newTestCA(t, "Other Root").Certificate)
+
+	store, err := LoadTrustStore(path)
+	if err != nil {
+		t.Fatal("Did not expect an error", err)
+	}
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	leaf, _ := ca.Issue(key.Public(), Request{Subject: pkix.Name{CommonName: "partner"}}, time.Hour)
+	if _, err := store.Verify(leaf, nil, VerifyOptions{}); err != nil {
+		t.Fatal("Expected the certificate to verify", err)
+	}
+
+	if _, err := LoadTrustStore(); err == nil {
+		t.Fatal("Expected an error without trust anchor")
+	}
+	if _, err := LoadTrustStore(filepath.Join(t.TempDir(), "missing.pem")); err == nil {
+		t.Fatal("Expected an error for a missing file")
+	}
+}
@@ -0,0 +1,34 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package chacha
+
+import (
+	"github.com/exohood/exohood-crypto-algorithms/algorithm"
+)
+
+var _ algorithm.AEAD = (*Cipher)(nil)
+
+func init() {
+	algorithm.RegisterAEAD(algorithm.ChaCha20Poly1305, aeadFactory(New))
+	algorithm.RegisterAEAD(algorithm.XChaCha20Poly1305, aeadFactory(NewX))
+}
+
+func aeadFactory(constructor func([]byte, ...Option) (Cipher, error)) algorithm.AEADFactory {
+	return func(keyBytes []byte) (algorithm.AEAD, error) {
+		cipher, err := constructor(keyBytes)
+		if err != nil {
+			return nil, err
+		}
+		return &cipher, nil
+	}
+}
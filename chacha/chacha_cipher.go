@@ -0,0 +1,176 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+// Package chacha provides wrapper methods on top of the ChaCha20-Poly1305 and XChaCha20-Poly1305
+// ciphers for our own usage, with the same semantics as the aes package
+package chacha
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/exohood/exohood-crypto-algorithms/usage"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// Cipher is wrapper of the ChaCha20-Poly1305 or XChaCha20-Poly1305 cipher and stores the raw key bytes
+type Cipher struct {
+	aead     cipher.AEAD
+	usage    *usage.Tracker
+	KeyBytes []byte
+}
+
+// Option configures optional behaviors of the Cipher
+type Option func(*Cipher)
+
+// WithUsageTracker accounts every encryption against the tracker, Encrypt refuses to run once
+// the tracker hard limit has been exceeded
+func WithUsageTracker(tracker *usage.Tracker) Option {
+	return func(cipher *Cipher) {
+		cipher.usage = tracker
+	}
+}
+
+// newCipher wraps the AEAD and applies the options
+func newCipher(aead cipher.AEAD, keyBytes []byte, opts []Option) Cipher {
+	c := Cipher{aead: aead, KeyBytes: keyBytes}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// New constructs a new ChaCha20-Poly1305 (RFC 8439) cipher with a 96-bit nonce using the raw key
+// bytes provided, the raw bytes must be 32 bytes
+func New(keyBytes []byte, opts ...Option) (Cipher, error) {
+	aead, err := chacha20poly1305.New(keyBytes)
+	if err != nil {
+		return Cipher{}, err
+	}
+	return newCipher(aead, keyBytes, opts), nil
+}
+
+// NewX constructs a new XChaCha20-Poly1305 cipher with a 192-bit nonce using the raw key bytes
+// provided, the raw bytes must be 32 bytes. Its random nonces are safe for practically unlimited
+// messages under a single key.
+func NewX(keyBytes []byte, opts ...Option) (Cipher, error) {
+	aead, err := chacha20poly1305.NewX(keyBytes)
+	if err != nil {
+		return Cipher{}, err
+	}
+	return newCipher(aead, keyBytes, opts), nil
+}
+
+// Encrypt takes plain bytes and output cipher bytes, the random nonce will be prefixed to
+// cipher bytes if prefixNonce is true.
+func (cipher *Cipher) Encrypt(plainBytes []byte, prefixNonce bool) ([]byte, []byte, error) {
+	nonce := make([]byte, cipher.aead.NonceSize())
+	if err := cipher.nextNonce(nonce); err != nil {
+		return nil, nil, err
+	}
+
+	cipherBytes := cipher.aead.Seal(nil, nonce, plainBytes, nil)
+	if prefixNonce {
+		cipherBytes = append(nonce, cipherBytes...)
+	}
+
+	return cipherBytes, nonce, nil
+}
+
+// Decrypt takes cipher bytes and output plain bytes, it is assumed the nonce is prefixed
+// to cipher bytes if its value is not being provided
+func (cipher *Cipher) Decrypt(cipherBytes []byte, nonce []byte) ([]byte, error) {
+	if nonce == nil {
+		nonceSize := cipher.aead.NonceSize()
+		if len(cipherBytes) < nonceSize {
+			return nil, errors.New("ciphertext is too short")
+		}
+		nonce, cipherBytes = cipherBytes[:nonceSize], cipherBytes[nonceSize:]
+	}
+
+	return cipher.aead.Open(nil, nonce, cipherBytes, nil)
+}
+
+// NonceSize returns the size of the nonce prefixed by Seal
+func (cipher *Cipher) NonceSize() int {
+	return cipher.aead.NonceSize()
+}
+
+// Overhead returns the maximum difference between the lengths of the sealed and the plain bytes,
+// excluding the nonce
+func (cipher *Cipher) Overhead() int {
+	return cipher.aead.Overhead()
+}
+
+// Seal encrypts the plain bytes like Encrypt with a prefixed nonce, and appends the nonce and the
+// cipher bytes to dst. It does not allocate when dst has enough capacity, i.e. NonceSize() +
+// len(plainBytes) + Overhead(). To encrypt in place, store the plain bytes at buf[NonceSize():]
+// and pass buf[:0] as dst.
+func (cipher *Cipher) Seal(dst []byte, plainBytes []byte) ([]byte, error) {
+	nonceSize := cipher.aead.NonceSize()
+	ret := slices.Grow(dst, nonceSize)[:len(dst)+nonceSize]
+	nonce := ret[len(dst):]
+	if err := cipher.nextNonce(nonce); err != nil {
+		return nil, err
+	}
+	return cipher.aead.Seal(ret, nonce, plainBytes, nil), nil
+}
+
+// Open decrypts the output of Seal, or of Encrypt with a prefixed nonce, and appends the plain
+// bytes to dst. To decrypt in place, pass sealed[NonceSize():NonceSize()] as dst.
+func (cipher *Cipher) Open(dst []byte, sealed []byte) ([]byte, error) {
+	nonceSize := cipher.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return nil, errors.New("chacha20poly1305: message authentication failed")
+	}
+	return cipher.aead.Open(dst, sealed[:nonceSize], sealed[nonceSize:], nil)
+}
+
+// SealWithNonce encrypts the plain bytes like Encrypt without a prefixed nonce, it writes a random
+// nonce into the nonce slice, which must be NonceSize() bytes, and appends the cipher bytes to dst.
+// To encrypt in place, pass plainBytes[:0] as dst.
+func (cipher *Cipher) SealWithNonce(dst []byte, nonce []byte, plainBytes []byte) ([]byte, error) {
+	if len(nonce) != cipher.aead.NonceSize() {
+		return nil, fmt.Errorf("nonce must be %d bytes", cipher.aead.NonceSize())
+	}
+	if err := cipher.nextNonce(nonce); err != nil {
+		return nil, err
+	}
+	return cipher.aead.Seal(dst, nonce, plainBytes, nil), nil
+}
+
+// OpenWithNonce decrypts the output of SealWithNonce and appends the plain bytes to dst. To decrypt
+// in place, pass cipherBytes[:0] as dst.
+func (cipher *Cipher) OpenWithNonce(dst []byte, nonce []byte, cipherBytes []byte) ([]byte, error) {
+	if len(nonce) != cipher.aead.NonceSize() {
+		return nil, fmt.Errorf("nonce must be %d bytes", cipher.aead.NonceSize())
+	}
+	return cipher.aead.Open(dst, nonce, cipherBytes, nil)
+}
+
+// nextNonce accounts the encryption against the usage tracker and fills a random nonce
+func (cipher *Cipher) nextNonce(nonce []byte) error {
+	if cipher.usage != nil {
+		if err := cipher.usage.Use(1); err != nil {
+			return err
+		}
+	}
+
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return errors.New("fail to generate nonce")
+	}
+	return nil
+}
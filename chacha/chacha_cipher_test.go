@@ -0,0 +1,170 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package chacha
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/exohood/exohood-crypto-algorithms/usage"
+	"github.com/hashicorp/go-uuid"
+)
+
+func TestChaCha20Poly1305_RFC8439Vector(t *testing.T) {
+	// RFC 8439 section 2.8.2
+	key, _ := hex.DecodeString("808182838485868788898a8b8c8d8e8f909192939495969798999a9b9c9d9e9f")
+	nonce, _ := hex.DecodeString("070000004041424344454647")
+	aad, _ := hex.DecodeString("50515253c0c1c2c3c4c5c6c7")
+	plaintext := []byte("Ladies and Gentlemen of the class of '99: If I could offer you only one tip for the future, sunscreen would be it.")
+	expected := "d31a8d34648e60db7b86afbc53ef7ec2a4aded51296e08fea9e2b5a736ee62d63dbea45e8ca9671282fafb69da92728b1a71de0a9e060b2905d6a5b67ecd3b3692ddbd7f2d778b8c9803aee328091b58fab324e4fad675945585808b4831d7bc3ff4def08e4b7a9de576d26586cec64b6116" +
+		"1ae10b594f09e26a7e902ecbd0600691"
+
+	cipher, err := New(key)
+	if err != nil {
+		t.Fatalf("Did not expect an error but got %q", err)
+	}
+
+	result := cipher.aead.Seal(nil, nonce, plaintext, aad)
+	if hex.EncodeToString(result) != expected {
+		t.Errorf("Expected %s but got %x", expected, result)
+	}
+}
+
+func TestChaCha20Poly1305_EncryptAndDecrypt(t *testing.T) {
+	keyBytes, _ := uuid.GenerateRandomBytes(32)
+	cipher, _ := New(keyBytes)
+	xcipher, _ := NewX(keyBytes)
+
+	testDatas := []string{
+		"my secret 1234",
+		"123456789",
+	}
+
+	for _, c := range []Cipher{cipher, xcipher} {
+		for _, testData := range testDatas {
+			cipherBytes, nonce, err := c.Encrypt([]byte(testData), false)
+			if err != nil {
+				t.Errorf("Did not expect an encryption error but got %q", err)
+			}
+			plainBytes, err := c.Decrypt(cipherBytes, nonce)
+			if err != nil || testData != string(plainBytes) {
+				t.Errorf("Expected %s but get %s (%v)", testData, string(plainBytes), err)
+			}
+
+			cipherBytes, _, _ = c.Encrypt([]byte(testData), true)
+			plainBytes, err = c.Decrypt(cipherBytes, nil)
+			if err != nil || testData != string(plainBytes) {
+				t.Errorf("Expected %s but get %s (%v)", testData, string(plainBytes), err)
+			}
+		}
+	}
+
+	if _, nonce, _ := xcipher.Encrypt([]byte("my secret 1234"), false); len(nonce) != 24 {
+		t.Errorf("Expected a 24 bytes nonce but got %d bytes", len(nonce))
+	}
+}
+
+func TestChaCha20Poly1305_InvalidInputs(t *testing.T) {
+	keyBytes, _ := uuid.GenerateRandomBytes(16)
+	if _, err := New(keyBytes); err == nil {
+		t.Error("should be an error for 16 bytes key")
+	}
+	if _, err := NewX(keyBytes); err == nil {
+		t.Error("should be an error for 16 bytes key")
+	}
+
+	keyBytes, _ = uuid.GenerateRandomBytes(32)
+	cipher, _ := NewX(keyBytes)
+	cipherBytes, _, _ := cipher.Encrypt([]byte("my secret 1234"), true)
+	cipherBytes[len(cipherBytes)-1] ^= 1
+	if _, err := cipher.Decrypt(cipherBytes, nil); err == nil {
+		t.Error("should be an error if the ciphertext has been modified")
+	}
+	if _, err := cipher.Decrypt(cipherBytes[:10], nil); err == nil {
+		t.Error("should be an error if the ciphertext is too short")
+	}
+}
+
+func TestChaCha20Poly1305_UsageLimits(t *testing.T) {
+	keyBytes, _ := uuid.GenerateRandomBytes(32)
+	tracker := usage.NewTracker("chacha-key", usage.NewMemoryStore(), usage.Limits{HardLimit: 2})
+	cipher, _ := New(keyBytes, WithUsageTracker(tracker))
+
+	if _, _, err := cipher.Encrypt([]byte("my secret 1234"), true); err != nil {
+		t.Errorf("Did not expect an encryption error but got %q", err)
+	}
+	if _, err := cipher.Seal(nil, []byte("my secret 1234")); err != nil {
+		t.Errorf("Did not expect an encryption error but got %q", err)
+	}
+	if _, err := cipher.SealWithNonce(nil, make([]byte, cipher.NonceSize()), []byte("my secret 1234")); !errors.Is(err, usage.ErrLimitExceeded) {
+		t.Errorf("Expected a limit exceeded error but got %v", err)
+	}
+}
+
+func TestChaCha20Poly1305_SealAndOpen(t *testing.T) {
+	keyBytes, _ := uuid.GenerateRandomBytes(32)
+	cipher, _ := New(keyBytes)
+	xcipher, _ := NewX(keyBytes)
+
+	plainBytes := []byte("4111111111111111")
+	for i, c := range []Cipher{cipher, xcipher} {
+		sealed, err := c.Seal([]byte("header"), plainBytes)
+		if err != nil {
+			t.Fatalf("#%d: did not expect an error but got %q", i, err)
+		}
+		if string(sealed[:6]) != "header" || len(sealed) != 6+c.NonceSize()+len(plainBytes)+c.Overhead() {
+			t.Errorf("#%d: expected the sealed bytes to be appended to dst", i)
+		}
+		decrypted, err := c.Decrypt(sealed[6:], nil)
+		if err != nil || !bytes.Equal(decrypted, plainBytes) {
+			t.Errorf("#%d: expected %s but got %s (%v)", i, plainBytes, decrypted, err)
+		}
+
+		// In place encryption and decryption within a single buffer
+		buf := make([]byte, c.NonceSize(), c.NonceSize()+len(plainBytes)+c.Overhead())
+		buf = append(buf, plainBytes...)
+		sealed, _ = c.Seal(buf[:0], buf[c.NonceSize():])
+		if &sealed[0] != &buf[0] {
+			t.Errorf("#%d: expected the sealing to happen in place", i)
+		}
+		opened, err := c.Open(sealed[c.NonceSize():c.NonceSize()], sealed)
+		if err != nil || !bytes.Equal(opened, plainBytes) {
+			t.Errorf("#%d: expected %s but got %s (%v)", i, plainBytes, opened, err)
+		}
+
+		nonce := make([]byte, c.NonceSize())
+		detached, _ := c.SealWithNonce(nil, nonce, plainBytes)
+		opened, err = c.OpenWithNonce(detached[:0], nonce, detached)
+		if err != nil || !bytes.Equal(opened, plainBytes) {
+			t.Errorf("#%d: expected %s but got %s (%v)", i, plainBytes, opened, err)
+		}
+
+		if _, err := c.Open(nil, sealed[:c.NonceSize()-1]); err == nil {
+			t.Errorf("#%d: should be an error if the sealed bytes are shorter than the nonce", i)
+		}
+		if _, err := c.SealWithNonce(nil, nonce[1:], plainBytes); err == nil {
+			t.Errorf("#%d: should be an error if the nonce size is invalid", i)
+		}
+	}
+
+	sealed := make([]byte, 0, cipher.NonceSize()+len(plainBytes)+cipher.Overhead())
+	opened := make([]byte, 0, len(plainBytes))
+	allocs := testing.AllocsPerRun(100, func() {
+		sealed, _ = cipher.Seal(sealed[:0], plainBytes)
+		opened, _ = cipher.Open(opened[:0], sealed)
+	})
+	if allocs != 0 {
+		t.Errorf("Expected no allocation but got %v per run", allocs)
+	}
+}
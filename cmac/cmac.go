@@ -0,0 +1,137 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+// Package cmac implements the CMAC message authentication code (NIST SP 800-38B, RFC 4493) on
+// top of a 64-bit or 128-bit block cipher
+package cmac
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"errors"
+	"hash"
+)
+
+const (
+	rb64  = 0x1b
+	rb128 = 0x87
+)
+
+type cmac struct {
+	block  cipher.Block
+	k1, k2 []byte
+	state  []byte
+	buffer []byte
+	offset int
+}
+
+// New returns a hash.Hash computing the CMAC of the given block cipher, the block size must be
+// either 8 (DES, 3DES) or 16 (AES) bytes
+func New(block cipher.Block) (hash.Hash, error) {
+	blockSize := block.BlockSize()
+
+	var rb byte
+	switch blockSize {
+	case 8:
+		rb = rb64
+	case 16:
+		rb = rb128
+	default:
+		return nil, errors.New("CMAC block size must be either 8 or 16 bytes")
+	}
+
+	// Derive the subkeys from the encryption of the zero block
+	l := make([]byte, blockSize)
+	block.Encrypt(l, l)
+	k1 := shiftLeft(l, rb)
+	k2 := shiftLeft(k1, rb)
+
+	return &cmac{
+		block:  block,
+		k1:     k1,
+		k2:     k2,
+		state:  make([]byte, blockSize),
+		buffer: make([]byte, blockSize),
+	}, nil
+}
+
+// Sum is a shortcut computing the CMAC of the message in one call
+func Sum(block cipher.Block, message []byte) ([]byte, error) {
+	mac, err := New(block)
+	if err != nil {
+		return nil, err
+	}
+	mac.Write(message)
+	return mac.Sum(nil), nil
+}
+
+func (c *cmac) Size() int {
+	return len(c.state)
+}
+
+func (c *cmac) BlockSize() int {
+	return len(c.state)
+}
+
+func (c *cmac) Reset() {
+	for i := range c.state {
+		c.state[i] = 0
+		c.buffer[i] = 0
+	}
+	c.offset = 0
+}
+
+// Write absorbs the message, the last (possibly full) block is always kept in the buffer since
+// it has to be masked with one of the subkeys when Sum is called
+func (c *cmac) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		if c.offset == len(c.buffer) {
+			subtle.XORBytes(c.state, c.state, c.buffer)
+			c.block.Encrypt(c.state, c.state)
+			c.offset = 0
+		}
+		copied := copy(c.buffer[c.offset:], p)
+		c.offset += copied
+		p = p[copied:]
+	}
+	return n, nil
+}
+
+func (c *cmac) Sum(in []byte) []byte {
+	blockSize := len(c.state)
+	last := make([]byte, blockSize)
+	copy(last, c.buffer[:c.offset])
+
+	if c.offset == blockSize {
+		subtle.XORBytes(last, last, c.k1)
+	} else {
+		last[c.offset] = 0x80
+		subtle.XORBytes(last, last, c.k2)
+	}
+
+	subtle.XORBytes(last, last, c.state)
+	c.block.Encrypt(last, last)
+	return append(in, last...)
+}
+
+// shiftLeft doubles the input in GF(2^n), reducing with rb when the most significant bit is set
+func shiftLeft(in []byte, rb byte) []byte {
+	out := make([]byte, len(in))
+	var carry byte
+	for i := len(in) - 1; i >= 0; i-- {
+		out[i] = in[i]<<1 | carry
+		carry = in[i] >> 7
+	}
+	out[len(out)-1] ^= byte(subtle.ConstantTimeSelect(int(carry), int(rb), 0))
+	return out
+}
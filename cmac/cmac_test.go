@@ -0,0 +1,79 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package cmac
+
+import (
+	"crypto/aes"
+	"crypto/des"
+	"encoding/hex"
+	"testing"
+)
+
+func TestAESCMAC_RFC4493Vectors(t *testing.T) {
+	keyBytes, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+	block, _ := aes.NewCipher(keyBytes)
+
+	testData := map[string]string{
+		"": "bb1d6929e95937287fa37d129b756746",
+		"6bc1bee22e409f96e93d7e117393172a": "070a16b46b4d4144f79bdd9dd04a287c",
+		"6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411": "dfa66747de9ae63030ca32611497c827",
+		"6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411e5fbc1191a0a52eff69f2445df4f9b17ad2b417be66c3710": "51f0bebf7e3b9d92fc49741779363cfe",
+	}
+
+	for message, expectedMAC := range testData {
+		messageBytes, _ := hex.DecodeString(message)
+		mac, err := Sum(block, messageBytes)
+		if err != nil {
+			t.Errorf("Did not expect an error but got %q", err)
+		}
+		if hex.EncodeToString(mac) != expectedMAC {
+			t.Errorf("Expected value %s but got %x instead", expectedMAC, mac)
+		}
+	}
+}
+
+func TestTripleDESCMAC_SP80038BVectors(t *testing.T) {
+	// NIST SP 800-38B appendix D.4, three key TDEA
+	keyBytes, _ := hex.DecodeString("8aa83bf8cbda10620bc1bf19fbb6cd58bc313d4a371ca8b5")
+	block, _ := des.NewTripleDESCipher(keyBytes)
+
+	testData := map[string]string{
+		"":                 "b7a688e122ffaf95",
+		"6bc1bee22e409f96": "8e8f293136283797",
+	}
+
+	for message, expectedMAC := range testData {
+		messageBytes, _ := hex.DecodeString(message)
+		mac, err := Sum(block, messageBytes)
+		if err != nil {
+			t.Errorf("Did not expect an error but got %q", err)
+		}
+		if hex.EncodeToString(mac) != expectedMAC {
+			t.Errorf("Expected value %s but got %x instead", expectedMAC, mac)
+		}
+	}
+}
+
+func TestCMAC_IncrementalWrites(t *testing.T) {
+	keyBytes, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+	block, _ := aes.NewCipher(keyBytes)
+	message, _ := hex.DecodeString("6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411")
+
+	mac, _ := New(block)
+	for _, b := range message {
+		mac.Write([]byte{b})
+	}
+
+	if hex.EncodeToString(mac.Sum(nil)) != "dfa66747de9ae63030ca32611497c827" {
+		t.Errorf("Expected value dfa66747de9ae63030ca32611497c827 but got %x instead", mac.Sum(nil))
+	}
+}
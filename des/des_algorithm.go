@@ -0,0 +1,37 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package des
+
+import (
+	"github.com/exohood/exohood-crypto-algorithms/algorithm"
+)
+
+var (
+	_ algorithm.BlockCipher           = (*Cipher)(nil)
+	_ algorithm.KeyCheckValueProvider = (*Cipher)(nil)
+)
+
+func init() {
+	algorithm.RegisterBlockCipher(algorithm.DES, blockCipherFactory(CreateFromDESKeyBytes))
+	algorithm.RegisterBlockCipher(algorithm.TripleDES, blockCipherFactory(CreateFromTripleDESKeyBytes))
+}
+
+func blockCipherFactory(constructor func([]byte, ...Option) (Cipher, error)) algorithm.BlockCipherFactory {
+	return func(keyBytes []byte) (algorithm.BlockCipher, error) {
+		cipher, err := constructor(keyBytes)
+		if err != nil {
+			return nil, err
+		}
+		return &cipher, nil
+	}
+}
@@ -16,7 +16,10 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
+
+	"github.com/exohood/exohood-crypto-algorithms/usage"
 )
 
 const (
@@ -30,19 +33,56 @@ var keyCheckValuePlainText8Bytes = []byte{0, 0, 0, 0, 0, 0, 0, 0}
 type Cipher struct {
 	KeyBlock cipher.Block
 	KeyBytes []byte
+	usage    *usage.Tracker
+}
+
+// Option configures optional behaviors of the Cipher
+type Option func(*Cipher)
+
+// WithUsageTracker accounts every encrypted block against the tracker, Encrypt refuses to run once
+// the tracker hard limit has been exceeded. Blocks encrypted through KeyBlock directly are not
+// accounted.
+func WithUsageTracker(tracker *usage.Tracker) Option {
+	return func(cipher *Cipher) {
+		cipher.usage = tracker
+	}
 }
 
 func (cipher *Cipher) Encrypt(plainBytes []byte) ([]byte, error) {
+	return cipher.Seal(make([]byte, 0, len(plainBytes)), plainBytes)
+}
+
+// Seal encrypts the plain bytes like Encrypt and appends the cipher bytes to dst, it does not
+// allocate when dst has enough capacity. To encrypt in place, pass plainBytes[:0] as dst.
+func (cipher *Cipher) Seal(dst []byte, plainBytes []byte) ([]byte, error) {
 	blockSize := cipher.KeyBlock.BlockSize()
 	if len(plainBytes)%blockSize != 0 {
 		return nil, fmt.Errorf("input length %d is not a multiplier of block size %d", len(plainBytes), blockSize)
 	}
 
+	if cipher.usage != nil {
+		if err := cipher.usage.Use(uint64(len(plainBytes) / blockSize)); err != nil {
+			return nil, err
+		}
+	}
+
+	ret := slices.Grow(dst, len(plainBytes))[:len(dst)+len(plainBytes)]
+	out := ret[len(dst):]
+	for start := 0; start+blockSize <= len(plainBytes); start += blockSize {
+		cipher.KeyBlock.Encrypt(out[start:], plainBytes[start:])
+	}
+	return ret, nil
+}
+
+// encryptBlocks encrypts the whole blocks without accounting them, it is used directly for the
+// check values which are not data encryptions
+func (cipher *Cipher) encryptBlocks(plainBytes []byte) []byte {
+	blockSize := cipher.KeyBlock.BlockSize()
 	cipherBytes := make([]byte, len(plainBytes))
 	for start := 0; start+blockSize <= len(plainBytes); start += blockSize {
 		cipher.KeyBlock.Encrypt(cipherBytes[start:], plainBytes[start:])
 	}
-	return cipherBytes, nil
+	return cipherBytes
 }
 
 func (cipher *Cipher) EncryptHex(plaintext string) ([]byte, error) {
@@ -54,16 +94,23 @@ func (cipher *Cipher) EncryptHex(plaintext string) ([]byte, error) {
 }
 
 func (cipher *Cipher) Decrypt(cipherBytes []byte) ([]byte, error) {
+	return cipher.Open(make([]byte, 0, len(cipherBytes)), cipherBytes)
+}
+
+// Open decrypts the cipher bytes like Decrypt and appends the plain bytes to dst, it does not
+// allocate when dst has enough capacity. To decrypt in place, pass cipherBytes[:0] as dst.
+func (cipher *Cipher) Open(dst []byte, cipherBytes []byte) ([]byte, error) {
 	blockSize := cipher.KeyBlock.BlockSize()
 	if len(cipherBytes)%blockSize != 0 {
 		return nil, fmt.Errorf("input length %d is not a multiplier of block size %d", len(cipherBytes), blockSize)
 	}
 
-	plainBytes := make([]byte, len(cipherBytes))
+	ret := slices.Grow(dst, len(cipherBytes))[:len(dst)+len(cipherBytes)]
+	out := ret[len(dst):]
 	for start := 0; start+blockSize <= len(cipherBytes); start += blockSize {
-		cipher.KeyBlock.Decrypt(plainBytes[start:], cipherBytes[start:])
+		cipher.KeyBlock.Decrypt(out[start:], cipherBytes[start:])
 	}
-	return plainBytes, nil
+	return ret, nil
 }
 
 func (cipher *Cipher) DecryptHex(ciphertext string) ([]byte, error) {
@@ -80,18 +127,12 @@ func (cipher *Cipher) VerifyCheckValue(checkValue string) bool {
 		return false
 	}
 
-	cipherBytes, err := cipher.Encrypt(keyCheckValuePlainText8Bytes)
-	if err != nil {
-		return false
-	}
+	cipherBytes := cipher.encryptBlocks(keyCheckValuePlainText8Bytes)
 	derivedCheckValue := hex.EncodeToString(cipherBytes[:checkValueBytes])
 	return strings.EqualFold(derivedCheckValue, checkValue)
 }
 
 func (cipher *Cipher) CheckValue() string {
-	cipherBytes, err := cipher.Encrypt(keyCheckValuePlainText8Bytes)
-	if err != nil {
-		return ""
-	}
+	cipherBytes := cipher.encryptBlocks(keyCheckValuePlainText8Bytes)
 	return hex.EncodeToString(cipherBytes[:checkValueDefaultBytes])
 }
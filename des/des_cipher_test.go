@@ -14,14 +14,17 @@ package des
 import (
 	"crypto/des"
 	"encoding/hex"
+	"errors"
 	"strings"
 	"testing"
+
+	"github.com/exohood/exohood-crypto-algorithms/usage"
 )
 
 func TestDESEncryption(t *testing.T) {
 	keyBytes, _ := hex.DecodeString("0123456789ABCDEF")
 	keyBlock, _ := des.NewCipher(keyBytes)
-	cipher := Cipher{keyBlock, keyBytes}
+	cipher := Cipher{KeyBlock: keyBlock, KeyBytes: keyBytes}
 
 	testData := map[string]string{
 		"":                                 "",
@@ -48,7 +51,7 @@ func TestDESEncryption(t *testing.T) {
 func TestTripleDESEncryption(t *testing.T) {
 	keyBytes, _ := hex.DecodeString("A1FA4BF45ECDA0C1198CF971365C148CA1FA4BF45ECDA0C1")
 	keyBlock, _ := des.NewTripleDESCipher(keyBytes)
-	cipher := Cipher{keyBlock, keyBytes}
+	cipher := Cipher{KeyBlock: keyBlock, KeyBytes: keyBytes}
 
 	testData := map[string]string{
 		"":                                 "",
@@ -75,7 +78,7 @@ func TestTripleDESEncryption(t *testing.T) {
 func TestDESDecryption(t *testing.T) {
 	keyBytes, _ := hex.DecodeString("0123456789ABCDEF")
 	keyBlock, _ := des.NewCipher(keyBytes)
-	cipher := Cipher{keyBlock, keyBytes}
+	cipher := Cipher{KeyBlock: keyBlock, KeyBytes: keyBytes}
 
 	testData := map[string]string{
 		"":                                 "",
@@ -102,7 +105,7 @@ func TestDESDecryption(t *testing.T) {
 func TestTripleDESDecryption(t *testing.T) {
 	keyBytes, _ := hex.DecodeString("A1FA4BF45ECDA0C1198CF971365C148CA1FA4BF45ECDA0C1")
 	keyBlock, _ := des.NewTripleDESCipher(keyBytes)
-	cipher := Cipher{keyBlock, keyBytes}
+	cipher := Cipher{KeyBlock: keyBlock, KeyBytes: keyBytes}
 
 	testData := map[string]string{
 		"":                                 "",
@@ -129,7 +132,7 @@ func TestTripleDESDecryption(t *testing.T) {
 func TestDESCheckValueVerification(t *testing.T) {
 	keyBytes, _ := hex.DecodeString("0123456789ABCDEF")
 	keyBlock, _ := des.NewCipher(keyBytes)
-	cipher := Cipher{keyBlock, keyBytes}
+	cipher := Cipher{KeyBlock: keyBlock, KeyBytes: keyBytes}
 
 	if !cipher.VerifyCheckValue("D5D44F") {
 		t.Error("expect checkValue to be valid")
@@ -143,7 +146,7 @@ func TestDESCheckValueVerification(t *testing.T) {
 func TestDESCheckValue(t *testing.T) {
 	keyBytes, _ := hex.DecodeString("0123456789ABCDEF")
 	keyBlock, _ := des.NewCipher(keyBytes)
-	cipher := Cipher{keyBlock, keyBytes}
+	cipher := Cipher{KeyBlock: keyBlock, KeyBytes: keyBytes}
 
 	if !strings.EqualFold(cipher.CheckValue(), "D5D44F") {
 		t.Error("expect checkValue to be valid")
@@ -153,7 +156,7 @@ func TestDESCheckValue(t *testing.T) {
 func TestTripleDESCheckValueVerification(t *testing.T) {
 	keyBytes, _ := hex.DecodeString("F94AC55104B0E5532D0A61D2D2C6C655F94AC55104B0E553")
 	keyBlock, _ := des.NewTripleDESCipher(keyBytes)
-	cipher := Cipher{keyBlock, keyBytes}
+	cipher := Cipher{KeyBlock: keyBlock, KeyBytes: keyBytes}
 
 	if !cipher.VerifyCheckValue("6FAAD3") {
 		t.Error("expect checkValue to be valid")
@@ -179,9 +182,84 @@ func TestTripleDESCheckValueVerification(t *testing.T) {
 func TestTripleDESCheckValue(t *testing.T) {
 	keyBytes, _ := hex.DecodeString("F94AC55104B0E5532D0A61D2D2C6C655F94AC55104B0E553")
 	keyBlock, _ := des.NewTripleDESCipher(keyBytes)
-	cipher := Cipher{keyBlock, keyBytes}
+	cipher := Cipher{KeyBlock: keyBlock, KeyBytes: keyBytes}
+
+	if !strings.EqualFold(cipher.CheckValue(), "6FAAD3") {
+		t.Error("expect checkValue to be valid")
+	}
+}
 
+func TestTripleDESUsageLimits(t *testing.T) {
+	tracker := usage.NewTracker("6FAAD3", usage.NewMemoryStore(), usage.Limits{HardLimit: 4})
+	cipher, _ := CreateFromTripleDESKeyString("F94AC55104B0E5532D0A61D2D2C6C655F94AC55104B0E553", WithUsageTracker(tracker))
+
+	// Check values are not accounted as data encryptions
 	if !strings.EqualFold(cipher.CheckValue(), "6FAAD3") {
 		t.Error("expect checkValue to be valid")
 	}
+
+	if _, err := cipher.EncryptHex("41234567890123454123456789012345"); err != nil {
+		t.Errorf("Did not expect an error but got %q", err)
+	}
+	if _, err := cipher.EncryptHex("41234567890123454123456789012345"); err != nil {
+		t.Errorf("Did not expect an error but got %q", err)
+	}
+	if _, err := cipher.EncryptHex("4123456789012345"); !errors.Is(err, usage.ErrLimitExceeded) {
+		t.Errorf("Expected a limit exceeded error but got %v", err)
+	}
+}
+
+func TestTripleDESSealAndOpen(t *testing.T) {
+	cipher, _ := CreateFromTripleDESKeyString("0123456789ABCDEFFEDCBA9876543210")
+	plainBytes, _ := hex.DecodeString("0000000000000000111111111111111122222222222222223333333333333333")
+
+	expected, _ := cipher.Encrypt(plainBytes)
+	sealed, err := cipher.Seal([]byte{0xff}, plainBytes)
+	if err != nil || hex.EncodeToString(sealed[1:]) != hex.EncodeToString(expected) || sealed[0] != 0xff {
+		t.Errorf("Expected ff%x but got %x (%v)", expected, sealed, err)
+	}
+
+	// In place
+	buf := append([]byte(nil), plainBytes...)
+	sealed, _ = cipher.Seal(buf[:0], buf)
+	opened, err := cipher.Open(sealed[:0], sealed)
+	if err != nil || hex.EncodeToString(opened) != hex.EncodeToString(plainBytes) || &opened[0] != &buf[0] {
+		t.Errorf("Expected %x decrypted in place but got %x (%v)", plainBytes, opened, err)
+	}
+
+	if _, err := cipher.Open(nil, plainBytes[:7]); err == nil {
+		t.Error("should be an error if the input is not a multiple of the block size")
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		sealed, _ = cipher.Seal(sealed[:0], plainBytes)
+		opened, _ = cipher.Open(opened[:0], sealed)
+	})
+	if allocs != 0 {
+		t.Errorf("Expected no allocation but got %v per run", allocs)
+	}
+}
+
+func BenchmarkTripleDESSeal(b *testing.B) {
+	cipher, _ := CreateFromTripleDESKeyString("0123456789ABCDEFFEDCBA9876543210")
+	plainBytes := make([]byte, 16)
+	sealed := make([]byte, 0, len(plainBytes))
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(plainBytes)))
+	for i := 0; i < b.N; i++ {
+		sealed, _ = cipher.Seal(sealed[:0], plainBytes)
+	}
+}
+
+func BenchmarkTripleDESOpen(b *testing.B) {
+	cipher, _ := CreateFromTripleDESKeyString("0123456789ABCDEFFEDCBA9876543210")
+	cipherBytes := make([]byte, 16)
+	opened := make([]byte, 0, len(cipherBytes))
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(cipherBytes)))
+	for i := 0; i < b.N; i++ {
+		opened, _ = cipher.Open(opened[:0], cipherBytes)
+	}
 }
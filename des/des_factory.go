@@ -13,12 +13,13 @@
 package des
 
 import (
+	"crypto/cipher"
 	"crypto/des"
 	"encoding/hex"
 	"errors"
 )
 
-func CreateFromDESKeyBytes(keyBytes []byte) (Cipher, error) {
+func CreateFromDESKeyBytes(keyBytes []byte, opts ...Option) (Cipher, error) {
 	if len(keyBytes) != 8 {
 		return Cipher{}, errors.New("DES key must be 8 bytes")
 	}
@@ -27,18 +28,18 @@ func CreateFromDESKeyBytes(keyBytes []byte) (Cipher, error) {
 	if err != nil {
 		return Cipher{}, errors.New("invalid DES keyBlock")
 	}
-	return Cipher{keyBlock, keyBytes}, nil
+	return newCipher(keyBlock, keyBytes, opts), nil
 }
 
-func CreateFromDESKeyString(key string) (Cipher, error) {
+func CreateFromDESKeyString(key string, opts ...Option) (Cipher, error) {
 	keyBytes, err := hex.DecodeString(key)
 	if err != nil {
 		return Cipher{}, errors.New("DES key is not in correct hex format")
 	}
-	return CreateFromDESKeyBytes(keyBytes)
+	return CreateFromDESKeyBytes(keyBytes, opts...)
 }
 
-func CreateFromTripleDESKeyBytes(keyBytes []byte) (Cipher, error) {
+func CreateFromTripleDESKeyBytes(keyBytes []byte, opts ...Option) (Cipher, error) {
 	if len(keyBytes) != 16 && len(keyBytes) != 24 {
 		return Cipher{}, errors.New("3DES key must be either 16 or 24 bytes")
 	}
@@ -51,13 +52,22 @@ func CreateFromTripleDESKeyBytes(keyBytes []byte) (Cipher, error) {
 	if err != nil {
 		return Cipher{}, errors.New("invalid 3DES keyBlock")
 	}
-	return Cipher{keyBlock, keyBytes}, nil
+	return newCipher(keyBlock, keyBytes, opts), nil
 }
 
-func CreateFromTripleDESKeyString(key string) (Cipher, error) {
+func CreateFromTripleDESKeyString(key string, opts ...Option) (Cipher, error) {
 	keyBytes, err := hex.DecodeString(key)
 	if err != nil {
 		return Cipher{}, errors.New("3DES key is not in correct hex format")
 	}
-	return CreateFromTripleDESKeyBytes(keyBytes)
+	return CreateFromTripleDESKeyBytes(keyBytes, opts...)
+}
+
+// newCipher wraps the key block and applies the options
+func newCipher(keyBlock cipher.Block, keyBytes []byte, opts []Option) Cipher {
+	c := Cipher{KeyBlock: keyBlock, KeyBytes: keyBytes}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
 }
@@ -0,0 +1,161 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+// Package fpe provides the FF1 and FF3-1 format-preserving encryption modes (NIST SP 800-38G)
+// on top of the AES block cipher
+package fpe
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const (
+	// minRadix and maxRadix bound the radix supported by both FF1 and FF3-1
+	minRadix = 2
+	maxRadix = 1 << 16
+	// minDomainSize is the minimum radix^minlen required by SP 800-38G Rev 1
+	minDomainSize = 1000000
+)
+
+// Alphabets of the most common radixes, the numeral of a character is its index in the alphabet
+const (
+	Decimal      = "0123456789"
+	Hexadecimal  = "0123456789abcdef"
+	Alphanumeric = "0123456789abcdefghijklmnopqrstuvwxyz"
+)
+
+// Cipher is the common shape of the FF1 and FF3-1 ciphers, the input and output are numeral
+// strings given as the list of their numerals in the cipher radix
+type Cipher interface {
+	Radix() int
+	Encrypt(numerals []uint16, tweak []byte) ([]uint16, error)
+	Decrypt(numerals []uint16, tweak []byte) ([]uint16, error)
+}
+
+// EncryptString encrypts a string whose characters all belong to the alphabet, the length of the
+// alphabet must match the cipher radix
+func EncryptString(cipher Cipher, alphabet string, plaintext string, tweak []byte) (string, error) {
+	numerals, err := toNumerals(cipher.Radix(), alphabet, plaintext)
+	if err != nil {
+		return "", err
+	}
+	encrypted, err := cipher.Encrypt(numerals, tweak)
+	if err != nil {
+		return "", err
+	}
+	return fromNumerals(alphabet, encrypted), nil
+}
+
+// DecryptString decrypts a string whose characters all belong to the alphabet, the length of the
+// alphabet must match the cipher radix
+func DecryptString(cipher Cipher, alphabet string, ciphertext string, tweak []byte) (string, error) {
+	numerals, err := toNumerals(cipher.Radix(), alphabet, ciphertext)
+	if err != nil {
+		return "", err
+	}
+	decrypted, err := cipher.Decrypt(numerals, tweak)
+	if err != nil {
+		return "", err
+	}
+	return fromNumerals(alphabet, decrypted), nil
+}
+
+func toNumerals(radix int, alphabet string, s string) ([]uint16, error) {
+	symbols := []rune(alphabet)
+	if len(symbols) != radix {
+		return nil, fmt.Errorf("alphabet size %d does not match radix %d", len(symbols), radix)
+	}
+
+	indexes := make(map[rune]uint16, len(symbols))
+	for i, symbol := range symbols {
+		indexes[symbol] = uint16(i)
+	}
+
+	numerals := make([]uint16, 0, len(s))
+	for _, r := range s {
+		index, ok := indexes[r]
+		if !ok {
+			return nil, fmt.Errorf("character %q is not in the alphabet", r)
+		}
+		numerals = append(numerals, index)
+	}
+	return numerals, nil
+}
+
+func fromNumerals(alphabet string, numerals []uint16) string {
+	symbols := []rune(alphabet)
+
+	var builder strings.Builder
+	for _, numeral := range numerals {
+		builder.WriteRune(symbols[numeral])
+	}
+	return builder.String()
+}
+
+// validateRadix checks the radix range and returns the minimum length for which the domain
+// radix^minlen is at least one million
+func validateRadix(radix int) (int, error) {
+	if radix < minRadix || radix > maxRadix {
+		return 0, fmt.Errorf("radix must be between %d and %d", minRadix, maxRadix)
+	}
+
+	minLen := 1
+	for domain := radix; domain < minDomainSize; domain *= radix {
+		minLen++
+	}
+	return minLen, nil
+}
+
+// validateNumerals checks every numeral is below the radix
+func validateNumerals(radix int, numerals []uint16) error {
+	for _, numeral := range numerals {
+		if int(numeral) >= radix {
+			return errors.New("numeral is not valid for the radix")
+		}
+	}
+	return nil
+}
+
+// num returns the number represented by the numerals in the radix, most significant first
+func num(radix *big.Int, numerals []uint16) *big.Int {
+	result := new(big.Int)
+	digit := new(big.Int)
+	for _, numeral := range numerals {
+		result.Mul(result, radix)
+		result.Add(result, digit.SetUint64(uint64(numeral)))
+	}
+	return result
+}
+
+// str writes x as m numerals in the radix, most significant first
+func str(radix *big.Int, m int, x *big.Int) []uint16 {
+	numerals := make([]uint16, m)
+	value := new(big.Int).Set(x)
+	remainder := new(big.Int)
+	for i := m - 1; i >= 0; i-- {
+		value.QuoRem(value, radix, remainder)
+		numerals[i] = uint16(remainder.Uint64())
+	}
+	return numerals
+}
+
+// reverse returns a reversed copy of the numerals or bytes
+func reverse[T any](in []T) []T {
+	out := make([]T, len(in))
+	for i := range in {
+		out[len(in)-1-i] = in[i]
+	}
+	return out
+}
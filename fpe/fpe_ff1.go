@@ -0,0 +1,171 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package fpe
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+)
+
+const (
+	ff1Rounds = 10
+	// ff1MaxLength is the maximum numeral string length, 2^32 in the standard, capped to keep the
+	// lengths in an int on every platform
+	ff1MaxLength = 1<<31 - 1
+)
+
+// FF1 is the FF1 format-preserving cipher of NIST SP 800-38G over the given radix
+type FF1 struct {
+	block          cipher.Block
+	radix          int
+	minLength      int
+	maxTweakLength int
+	KeyBytes       []byte
+}
+
+// NewFF1 constructs a new FF1 cipher using the raw AES key bytes provided, the raw bytes must be
+// either 16, 24, or 32 bytes. Tweaks longer than maxTweakLength bytes are rejected.
+func NewFF1(keyBytes []byte, radix int, maxTweakLength int) (*FF1, error) {
+	minLength, err := validateRadix(radix)
+	if err != nil {
+		return nil, err
+	}
+	if maxTweakLength < 0 {
+		return nil, fmt.Errorf("invalid maximum tweak length %d", maxTweakLength)
+	}
+
+	block, err := aes.NewCipher(keyBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FF1{block, radix, minLength, maxTweakLength, keyBytes}, nil
+}
+
+// Radix returns the radix of the numeral strings
+func (f *FF1) Radix() int {
+	return f.radix
+}
+
+// Encrypt encrypts the numeral string with the tweak, the output has the same length
+func (f *FF1) Encrypt(numerals []uint16, tweak []byte) ([]uint16, error) {
+	return f.crypt(numerals, tweak, true)
+}
+
+// Decrypt decrypts the numeral string with the tweak used for encryption
+func (f *FF1) Decrypt(numerals []uint16, tweak []byte) ([]uint16, error) {
+	return f.crypt(numerals, tweak, false)
+}
+
+// crypt runs algorithm 7 (FF1.Encrypt) or algorithm 8 (FF1.Decrypt) of SP 800-38G
+func (f *FF1) crypt(numerals []uint16, tweak []byte, encrypt bool) ([]uint16, error) {
+	n := len(numerals)
+	if n < f.minLength || n > ff1MaxLength {
+		return nil, fmt.Errorf("input length must be between %d and %d numerals", f.minLength, ff1MaxLength)
+	}
+	if len(tweak) > f.maxTweakLength {
+		return nil, fmt.Errorf("tweak must be at most %d bytes", f.maxTweakLength)
+	}
+	if err := validateNumerals(f.radix, numerals); err != nil {
+		return nil, err
+	}
+
+	t := len(tweak)
+	u := n / 2
+	v := n - u
+	radix := big.NewInt(int64(f.radix))
+	a, b := numerals[:u], numerals[u:]
+
+	// b is the byte length of the largest v numerals value, d the byte length of y
+	bLen := (new(big.Int).Sub(new(big.Int).Exp(radix, big.NewInt(int64(v)), nil), big.NewInt(1)).BitLen() + 7) / 8
+	d := 4*((bLen+3)/4) + 4
+
+	p := []byte{1, 2, 1, 0, 0, 0, 10, byte(u), 0, 0, 0, 0, 0, 0, 0, 0}
+	p[3], p[4], p[5] = byte(f.radix>>16), byte(f.radix>>8), byte(f.radix)
+	binary.BigEndian.PutUint32(p[8:12], uint32(n))
+	binary.BigEndian.PutUint32(p[12:16], uint32(t))
+
+	// Q = T || [0]^((-t-b-1) mod 16) || [i]^1 || [NUM(B)]^b
+	padding := ((-t-bLen-1)%16 + 16) % 16
+	q := make([]byte, t+padding+1+bLen)
+	copy(q, tweak)
+
+	modulusU := new(big.Int).Exp(radix, big.NewInt(int64(u)), nil)
+	modulusV := new(big.Int).Exp(radix, big.NewInt(int64(v)), nil)
+
+	for round := 0; round < ff1Rounds; round++ {
+		i := round
+		if !encrypt {
+			i = ff1Rounds - 1 - round
+		}
+
+		source := b
+		if !encrypt {
+			source = a
+		}
+		q[t+padding] = byte(i)
+		num(radix, source).FillBytes(q[t+padding+1:])
+
+		y := new(big.Int).SetBytes(f.expand(f.prf(p, q), d))
+
+		m, modulus := u, modulusU
+		if i%2 == 1 {
+			m, modulus = v, modulusV
+		}
+
+		if encrypt {
+			c := num(radix, a)
+			c.Add(c, y).Mod(c, modulus)
+			a, b = b, str(radix, m, c)
+		} else {
+			c := num(radix, b)
+			c.Sub(c, y).Mod(c, modulus)
+			b, a = a, str(radix, m, c)
+		}
+	}
+
+	return append(append(make([]uint16, 0, n), a...), b...), nil
+}
+
+// prf is the CBC-MAC of P || Q under the key with a zero IV
+func (f *FF1) prf(p, q []byte) []byte {
+	r := make([]byte, aes.BlockSize)
+	for _, input := range [][]byte{p, q} {
+		for start := 0; start < len(input); start += aes.BlockSize {
+			for j := 0; j < aes.BlockSize; j++ {
+				r[j] ^= input[start+j]
+			}
+			f.block.Encrypt(r, r)
+		}
+	}
+	return r
+}
+
+// expand builds S as the first d bytes of R || CIPH(R xor [1]^16) || CIPH(R xor [2]^16) ...
+func (f *FF1) expand(r []byte, d int) []byte {
+	s := make([]byte, 0, d+aes.BlockSize)
+	s = append(s, r...)
+
+	block := make([]byte, aes.BlockSize)
+	for j := 1; len(s) < d; j++ {
+		copy(block, r)
+		counter := binary.BigEndian.Uint64(block[8:]) ^ uint64(j)
+		binary.BigEndian.PutUint64(block[8:], counter)
+		f.block.Encrypt(block, block)
+		s = append(s, block...)
+	}
+	return s[:d]
+}
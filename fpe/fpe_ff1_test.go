@@ -0,0 +1,80 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package fpe
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestFF1_NISTSamples(t *testing.T) {
+	// NIST FF1 samples 1 to 3 (AES-128) and 7 (AES-256)
+	testDatas := []struct {
+		key, tweak, alphabet, plaintext, ciphertext string
+	}{
+		{"2b7e151628aed2a6abf7158809cf4f3c", "", Decimal, "0123456789", "2433477484"},
+		{"2b7e151628aed2a6abf7158809cf4f3c", "39383736353433323130", Decimal, "0123456789", "6124200773"},
+		{"2b7e151628aed2a6abf7158809cf4f3c", "3737373770717273373737", Alphanumeric, "0123456789abcdefghi", "a9tv40mll9kdu509eum"},
+		{"2b7e151628aed2a6abf7158809cf4f3cef4359d8d580aa4f7f036d6f04fc6a94", "", Decimal, "0123456789", "6657667009"},
+	}
+
+	for _, testData := range testDatas {
+		key, _ := hex.DecodeString(testData.key)
+		tweak, _ := hex.DecodeString(testData.tweak)
+
+		ff1, err := NewFF1(key, len(testData.alphabet), 16)
+		if err != nil {
+			t.Fatalf("Did not expect an error but got %q", err)
+		}
+
+		ciphertext, err := EncryptString(ff1, testData.alphabet, testData.plaintext, tweak)
+		if err != nil {
+			t.Errorf("Did not expect an encryption error but got %q", err)
+		}
+		if ciphertext != testData.ciphertext {
+			t.Errorf("Expected %s but got %s", testData.ciphertext, ciphertext)
+		}
+
+		plaintext, err := DecryptString(ff1, testData.alphabet, ciphertext, tweak)
+		if err != nil {
+			t.Errorf("Did not expect a decryption error but got %q", err)
+		}
+		if plaintext != testData.plaintext {
+			t.Errorf("Expected %s but got %s", testData.plaintext, plaintext)
+		}
+	}
+}
+
+func TestFF1_InvalidInputs(t *testing.T) {
+	key, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+
+	if _, err := NewFF1(key, 1, 16); err == nil {
+		t.Error("should be an error if the radix is below 2")
+	}
+	if _, err := NewFF1(key[:10], 10, 16); err == nil {
+		t.Error("should be an error if the key is not a valid AES key")
+	}
+
+	ff1, _ := NewFF1(key, 10, 4)
+	if _, err := EncryptString(ff1, Decimal, "12345", nil); err == nil {
+		t.Error("should be an error if the input is below the minimum length")
+	}
+	if _, err := EncryptString(ff1, Decimal, "0123456789", []byte("too long")); err == nil {
+		t.Error("should be an error if the tweak is too long")
+	}
+	if _, err := EncryptString(ff1, Decimal, "01234x6789", nil); err == nil {
+		t.Error("should be an error if the input is not in the alphabet")
+	}
+	if _, err := EncryptString(ff1, Hexadecimal, "0123456789", nil); err == nil {
+		t.Error("should be an error if the alphabet does not match the radix")
+	}
+}
@@ -0,0 +1,154 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package fpe
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"fmt"
+	"math/big"
+)
+
+const (
+	ff3Rounds = 8
+	// FF31TweakSize is the 56-bit tweak size of FF3-1
+	FF31TweakSize = 7
+)
+
+// FF31 is the FF3-1 format-preserving cipher of NIST SP 800-38G Rev 1 over the given radix
+type FF31 struct {
+	block     cipher.Block
+	radix     int
+	minLength int
+	maxLength int
+	KeyBytes  []byte
+}
+
+// NewFF31 constructs a new FF3-1 cipher using the raw AES key bytes provided, the raw bytes must
+// be either 16, 24, or 32 bytes
+func NewFF31(keyBytes []byte, radix int) (*FF31, error) {
+	minLength, err := validateRadix(radix)
+	if err != nil {
+		return nil, err
+	}
+
+	// FF3-1 runs AES under the byte reversed key
+	block, err := aes.NewCipher(reverse(keyBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	// maxlen = 2 * floor(log_radix(2^96))
+	bound := new(big.Int).Lsh(big.NewInt(1), 96)
+	radixInt := big.NewInt(int64(radix))
+	digits := 0
+	for power := new(big.Int).Set(radixInt); power.Cmp(bound) <= 0; power.Mul(power, radixInt) {
+		digits++
+	}
+
+	return &FF31{block, radix, minLength, 2 * digits, keyBytes}, nil
+}
+
+// Radix returns the radix of the numeral strings
+func (f *FF31) Radix() int {
+	return f.radix
+}
+
+// Encrypt encrypts the numeral string with the 7 bytes tweak, the output has the same length
+func (f *FF31) Encrypt(numerals []uint16, tweak []byte) ([]uint16, error) {
+	tweak64, err := expandFF31Tweak(tweak)
+	if err != nil {
+		return nil, err
+	}
+	return f.crypt(numerals, tweak64, true)
+}
+
+// Decrypt decrypts the numeral string with the 7 bytes tweak used for encryption
+func (f *FF31) Decrypt(numerals []uint16, tweak []byte) ([]uint16, error) {
+	tweak64, err := expandFF31Tweak(tweak)
+	if err != nil {
+		return nil, err
+	}
+	return f.crypt(numerals, tweak64, false)
+}
+
+// expandFF31Tweak splits the 56-bit tweak into the 64-bit T_L || T_R of the FF3 rounds:
+// T_L = T[0..27] || 0^4 and T_R = T[32..55] || T[28..31] || 0^4
+func expandFF31Tweak(tweak []byte) ([]byte, error) {
+	if len(tweak) != FF31TweakSize {
+		return nil, fmt.Errorf("FF3-1 tweak must be %d bytes", FF31TweakSize)
+	}
+	return []byte{
+		tweak[0], tweak[1], tweak[2], tweak[3] & 0xf0,
+		tweak[4], tweak[5], tweak[6], tweak[3] << 4,
+	}, nil
+}
+
+// crypt runs algorithm 9 (FF3.Encrypt) or algorithm 10 (FF3.Decrypt) with a 64-bit tweak
+func (f *FF31) crypt(numerals []uint16, tweak []byte, encrypt bool) ([]uint16, error) {
+	n := len(numerals)
+	if n < f.minLength || n > f.maxLength {
+		return nil, fmt.Errorf("input length must be between %d and %d numerals", f.minLength, f.maxLength)
+	}
+	if err := validateNumerals(f.radix, numerals); err != nil {
+		return nil, err
+	}
+
+	u := (n + 1) / 2
+	v := n - u
+	radix := big.NewInt(int64(f.radix))
+	a, b := numerals[:u], numerals[u:]
+	tweakLeft, tweakRight := tweak[:4], tweak[4:]
+
+	modulusU := new(big.Int).Exp(radix, big.NewInt(int64(u)), nil)
+	modulusV := new(big.Int).Exp(radix, big.NewInt(int64(v)), nil)
+
+	p := make([]byte, aes.BlockSize)
+	s := make([]byte, aes.BlockSize)
+	for round := 0; round < ff3Rounds; round++ {
+		i := round
+		if !encrypt {
+			i = ff3Rounds - 1 - round
+		}
+
+		m, modulus, w := u, modulusU, tweakRight
+		if i%2 == 1 {
+			m, modulus, w = v, modulusV, tweakLeft
+		}
+
+		// P = W xor [i]^4 || [NUM(REV(B))]^12
+		source := b
+		if !encrypt {
+			source = a
+		}
+		copy(p, w)
+		p[3] ^= byte(i)
+		num(radix, reverse(source)).FillBytes(p[4:])
+
+		// S = REVB(CIPH_REVB(K)(REVB(P)))
+		f.block.Encrypt(s, reverse(p))
+		y := new(big.Int).SetBytes(reverse(s))
+
+		if encrypt {
+			c := num(radix, reverse(a))
+			c.Add(c, y).Mod(c, modulus)
+			a, b = b, reverse(str(radix, m, c))
+		} else {
+			c := num(radix, reverse(b))
+			c.Sub(c, y).Mod(c, modulus)
+			b, a = a, reverse(str(radix, m, c))
+		}
+	}
+
+	return append(append(make([]uint16, 0, n), a...), b...), nil
+}
@@ -0,0 +1,106 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package fpe
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestFF3_NISTSamples(t *testing.T) {
+	// NIST FF3 samples, run through the FF3-1 rounds with their original 64-bit tweaks
+	testDatas := []struct {
+		key, tweak, plaintext, ciphertext string
+	}{
+		{"ef4359d8d580aa4f7f036d6f04fc6a94", "d8e7920afa330a73", "890121234567890000", "750918814058654607"},
+		{"ef4359d8d580aa4f7f036d6f04fc6a94", "9a768a92f60e12d8", "890121234567890000", "018989839189395384"},
+	}
+
+	for _, testData := range testDatas {
+		key, _ := hex.DecodeString(testData.key)
+		tweak, _ := hex.DecodeString(testData.tweak)
+
+		ff3, err := NewFF31(key, 10)
+		if err != nil {
+			t.Fatalf("Did not expect an error but got %q", err)
+		}
+
+		numerals, _ := toNumerals(10, Decimal, testData.plaintext)
+		encrypted, err := ff3.crypt(numerals, tweak, true)
+		if err != nil {
+			t.Errorf("Did not expect an encryption error but got %q", err)
+		}
+		if fromNumerals(Decimal, encrypted) != testData.ciphertext {
+			t.Errorf("Expected %s but got %s", testData.ciphertext, fromNumerals(Decimal, encrypted))
+		}
+
+		decrypted, _ := ff3.crypt(encrypted, tweak, false)
+		if fromNumerals(Decimal, decrypted) != testData.plaintext {
+			t.Errorf("Expected %s but got %s", testData.plaintext, fromNumerals(Decimal, decrypted))
+		}
+	}
+}
+
+func TestFF31_EncryptAndDecrypt(t *testing.T) {
+	key, _ := hex.DecodeString("ad41ec5d2356deae53ae76f50b4ba6d2")
+	tweak, _ := hex.DecodeString("cf29da1e18d970")
+
+	ff31, _ := NewFF31(key, 10)
+
+	testDatas := []string{
+		"6520935496",
+		"890121234567890000",
+		"4000000000000000000000000000000000000000000000000000000",
+	}
+
+	for _, testData := range testDatas {
+		ciphertext, err := EncryptString(ff31, Decimal, testData, tweak)
+		if err != nil {
+			t.Errorf("Did not expect an encryption error but got %q", err)
+		}
+		if len(ciphertext) != len(testData) || ciphertext == testData {
+			t.Errorf("Expected a different %d digits ciphertext but got %s", len(testData), ciphertext)
+		}
+
+		plaintext, err := DecryptString(ff31, Decimal, ciphertext, tweak)
+		if err != nil {
+			t.Errorf("Did not expect a decryption error but got %q", err)
+		}
+		if plaintext != testData {
+			t.Errorf("Expected %s but got %s", testData, plaintext)
+		}
+	}
+}
+
+func TestFF31_TweakExpansion(t *testing.T) {
+	tweak, _ := hex.DecodeString("d8e7920afa330a")
+	expanded, _ := expandFF31Tweak(tweak)
+
+	if hex.EncodeToString(expanded) != "d8e79200fa330aa0" {
+		t.Errorf("Expected d8e79200fa330aa0 but got %x", expanded)
+	}
+}
+
+func TestFF31_InvalidInputs(t *testing.T) {
+	key, _ := hex.DecodeString("ef4359d8d580aa4f7f036d6f04fc6a94")
+	ff31, _ := NewFF31(key, 10)
+
+	if _, err := EncryptString(ff31, Decimal, "890121234567890000", []byte("short")); err == nil {
+		t.Error("should be an error if the tweak is not 7 bytes")
+	}
+	if _, err := EncryptString(ff31, Decimal, "12345", make([]byte, FF31TweakSize)); err == nil {
+		t.Error("should be an error if the input is below the minimum length")
+	}
+	if _, err := EncryptString(ff31, Decimal, "12345678901234567890123456789012345678901234567890123456789", make([]byte, FF31TweakSize)); err == nil {
+		t.Error("should be an error if the input is above the maximum length")
+	}
+}
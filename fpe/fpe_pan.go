@@ -0,0 +1,114 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package fpe
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	panBINLength      = 6
+	panLastFourLength = 4
+	// panMinMiddleLength is the minimum length of FF1 and FF3-1 in radix 10, 10^6 >= 1000000
+	panMinMiddleLength = 6
+	panMinLength       = 12
+	panMaxLength       = 19
+)
+
+// EncryptPAN encrypts the digits of the PAN between the 6 digits BIN and the last four digits
+// with a radix 10 cipher. The output keeps the BIN and the last four digits, and the encrypted
+// digits are cycle-walked until the Luhn check digit of the output is valid again (or stays
+// invalid for a test PAN which did not pass the Luhn check). PANs shorter than 16 digits keep
+// only the first len(pan)-10 digits of the BIN, so that 6 digits are left to encrypt, e.g. the
+// first 5 digits of a 15 digits PAN.
+func EncryptPAN(cipher Cipher, pan string, tweak []byte) (string, error) {
+	return cryptPAN(cipher, pan, tweak, cipher.Encrypt)
+}
+
+// DecryptPAN reverses EncryptPAN with the same cipher and tweak
+func DecryptPAN(cipher Cipher, pan string, tweak []byte) (string, error) {
+	return cryptPAN(cipher, pan, tweak, cipher.Decrypt)
+}
+
+// LuhnCheckDigit computes the Luhn check digit to append to the digits
+func LuhnCheckDigit(digits string) (byte, error) {
+	sum, err := luhnSum(digits, true)
+	if err != nil {
+		return 0, err
+	}
+	return byte('0' + (10-sum%10)%10), nil
+}
+
+// ValidLuhn returns whether the last digit of the number is its valid Luhn check digit
+func ValidLuhn(number string) bool {
+	sum, err := luhnSum(number, false)
+	return err == nil && len(number) > 1 && sum%10 == 0
+}
+
+// luhnSum sums the digits doubling every second digit from the right, starting with the right
+// most one when the check digit is not part of the digits yet
+func luhnSum(digits string, doubleFirst bool) (int, error) {
+	sum := 0
+	double := doubleFirst
+	for i := len(digits) - 1; i >= 0; i-- {
+		if digits[i] < '0' || digits[i] > '9' {
+			return 0, errors.New("input must only contain digits")
+		}
+
+		digit := int(digits[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+	return sum, nil
+}
+
+func cryptPAN(cipher Cipher, pan string, tweak []byte, crypt func([]uint16, []byte) ([]uint16, error)) (string, error) {
+	if cipher.Radix() != 10 {
+		return "", errors.New("PAN encryption requires a radix 10 cipher")
+	}
+	if len(pan) < panMinLength || len(pan) > panMaxLength {
+		return "", fmt.Errorf("PAN must be between %d and %d digits", panMinLength, panMaxLength)
+	}
+
+	numerals, err := toNumerals(10, Decimal, pan)
+	if err != nil {
+		return "", errors.New("PAN must only contain digits")
+	}
+
+	binLength := min(panBINLength, len(pan)-panLastFourLength-panMinMiddleLength)
+	bin := pan[:binLength]
+	lastFour := pan[len(pan)-panLastFourLength:]
+	valid := ValidLuhn(pan)
+
+	// Cycle walking keeps the permutation within the PANs of the same Luhn validity, so that
+	// decryption walks back through the exact same values
+	middle := numerals[binLength : len(pan)-panLastFourLength]
+	for {
+		middle, err = crypt(middle, tweak)
+		if err != nil {
+			return "", err
+		}
+
+		candidate := bin + fromNumerals(Decimal, middle) + lastFour
+		if ValidLuhn(candidate) == valid {
+			return candidate, nil
+		}
+	}
+}
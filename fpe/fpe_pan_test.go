@@ -0,0 +1,98 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package fpe
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-uuid"
+)
+
+func TestLuhn(t *testing.T) {
+	if digit, _ := LuhnCheckDigit("411111111111111"); digit != '1' {
+		t.Errorf("Expected check digit 1 but got %c", digit)
+	}
+	if digit, _ := LuhnCheckDigit("7992739871"); digit != '3' {
+		t.Errorf("Expected check digit 3 but got %c", digit)
+	}
+	if !ValidLuhn("4111111111111111") || !ValidLuhn("79927398713") {
+		t.Error("Expected the numbers to pass the Luhn check")
+	}
+	if ValidLuhn("4111111111111112") || ValidLuhn("41111a1111111111") {
+		t.Error("Expected the numbers to fail the Luhn check")
+	}
+}
+
+func TestPAN_EncryptAndDecrypt(t *testing.T) {
+	keyBytes, _ := uuid.GenerateRandomBytes(32)
+	ff1, _ := NewFF1(keyBytes, 10, 16)
+	ff31, _ := NewFF31(keyBytes, 10)
+	tweak := []byte("pan-tok")
+
+	testDatas := []string{
+		"4111111111111111",
+		"5500005555555559",
+		"4111111111111112",
+		"6011000990139424123",
+		// 19, 15 (American Express) and 12 digits, the shorter ones keep less of the BIN
+		"6212345678901234561",
+		"378282246310005",
+		"501800000009",
+	}
+
+	for _, cipher := range []Cipher{ff1, ff31} {
+		for _, pan := range testDatas {
+			encrypted, err := EncryptPAN(cipher, pan, tweak)
+			if err != nil {
+				t.Fatalf("Did not expect an encryption error but got %q", err)
+			}
+			kept := min(6, len(pan)-10)
+			if len(encrypted) != len(pan) || encrypted[:kept] != pan[:kept] || encrypted[len(pan)-4:] != pan[len(pan)-4:] {
+				t.Errorf("Expected BIN and last four of %s to be kept but got %s", pan, encrypted)
+			}
+			if ValidLuhn(encrypted) != ValidLuhn(pan) {
+				t.Errorf("Expected the Luhn validity of %s to be kept but got %s", pan, encrypted)
+			}
+
+			decrypted, err := DecryptPAN(cipher, encrypted, tweak)
+			if err != nil {
+				t.Errorf("Did not expect a decryption error but got %q", err)
+			}
+			if decrypted != pan {
+				t.Errorf("Expected %s but got %s", pan, decrypted)
+			}
+		}
+	}
+}
+
+func TestPAN_InvalidInputs(t *testing.T) {
+	keyBytes, _ := uuid.GenerateRandomBytes(16)
+	ff1, _ := NewFF1(keyBytes, 10, 16)
+	hexFF1, _ := NewFF1(keyBytes, 16, 16)
+
+	invalidPANs := []string{
+		"",
+		"41111111111",
+		"411111111111111111111",
+		"411111111111111a",
+	}
+
+	for _, pan := range invalidPANs {
+		if _, err := EncryptPAN(ff1, pan, nil); err == nil {
+			t.Errorf("Expecting PAN %s to be invalid", pan)
+		}
+	}
+
+	if _, err := EncryptPAN(hexFF1, "4111111111111111", nil); err == nil {
+		t.Error("should be an error if the cipher radix is not 10")
+	}
+}
@@ -2,11 +2,10 @@ module github.com/exohood/exohood-crypto-algorithms
 
 go 1.22.0
 
-replace golang.org/x/crypto => github.com/ProtonMail/crypto v0.0.0-20201112134528-b4bfec6bba36
-
 require (
 	github.com/ProtonMail/gopenpgp/v2 v2.9.0
 	github.com/hashicorp/go-uuid v1.0.3
+	golang.org/x/crypto v0.33.0
 )
 
 require (
@@ -16,7 +15,6 @@ require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
 	github.com/stretchr/testify v1.7.1 // indirect
-	golang.org/x/crypto v0.33.0 // indirect
 	golang.org/x/sys v0.30.0 // indirect
 	golang.org/x/text v0.22.0 // indirect
 	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b // indirect
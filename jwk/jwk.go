@@ -0,0 +1,376 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+// Package jwk converts RSA, EC and OKP keys from and to JSON Web Keys (RFC 7517, RFC 7518 and
+// RFC 8037), reads and writes JWK sets and computes RFC 7638 thumbprints
+package jwk
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// Key types
+const (
+	KeyTypeRSA = "RSA"
+	KeyTypeEC  = "EC"
+	KeyTypeOKP = "OKP"
+)
+
+// Curves of the EC and OKP key types
+const (
+	CurveP256    = "P-256"
+	CurveP384    = "P-384"
+	CurveP521    = "P-521"
+	CurveEd25519 = "Ed25519"
+	CurveX25519  = "X25519"
+)
+
+// Key is a JSON Web Key, the binary members are base64url encoded without padding. Private keys
+// hold the private members, see Public to publish them.
+type Key struct {
+	KeyType string   `json:"kty"`
+	Use     string   `json:"use,omitempty"`
+	KeyOps  []string `json:"key_ops,omitempty"`
+	Alg     string   `json:"alg,omitempty"`
+	KeyID   string   `json:"kid,omitempty"`
+
+	// EC and OKP members
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+
+	// RSA members
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// Private members, D is shared by all the key types
+	D  string `json:"d,omitempty"`
+	P  string `json:"p,omitempty"`
+	Q  string `json:"q,omitempty"`
+	DP string `json:"dp,omitempty"`
+	DQ string `json:"dq,omitempty"`
+	QI string `json:"qi,omitempty"`
+}
+
+// The curve OIDs of RFC 5480, to build the DER structures parsed by crypto/x509
+var (
+	oidPublicKeyECDSA = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	curveOIDs         = map[string]asn1.ObjectIdentifier{
+		CurveP256: {1, 2, 840, 10045, 3, 1, 7},
+		CurveP384: {1, 3, 132, 0, 34},
+		CurveP521: {1, 3, 132, 0, 35},
+	}
+	// coordinateSizes are the sizes of x, y and d, which RFC 7518 requires to be left padded
+	coordinateSizes = map[string]int{
+		CurveP256: 32,
+		CurveP384: 48,
+		CurveP521: 66,
+	}
+)
+
+type subjectPublicKeyInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+type ecPrivateKey struct {
+	Version       int
+	PrivateKey    []byte
+	NamedCurveOID asn1.ObjectIdentifier `asn1:"optional,explicit,tag:0"`
+	PublicKey     asn1.BitString        `asn1:"optional,explicit,tag:1"`
+}
+
+// Parse decodes a JSON Web Key
+func Parse(data []byte) (Key, error) {
+	var key Key
+	if err := json.Unmarshal(data, &key); err != nil {
+		return Key{}, err
+	}
+	if key.KeyType == "" {
+		return Key{}, errors.New("JWK has no kty member")
+	}
+	return key, nil
+}
+
+// FromPublicKey converts an *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey or X25519
+// *ecdh.PublicKey to a JWK
+func FromPublicKey(publicKey crypto.PublicKey) (Key, error) {
+	switch pub := publicKey.(type) {
+	case *rsa.PublicKey:
+		return Key{KeyType: KeyTypeRSA, N: encode(pub.N.Bytes()), E: encode(big.NewInt(int64(pub.E)).Bytes())}, nil
+	case *ecdsa.PublicKey:
+		curve := pub.Curve.Params().Name
+		if _, ok := coordinateSizes[curve]; !ok {
+			return Key{}, fmt.Errorf("unsupported EC curve %q", curve)
+		}
+		ecdhKey, err := pub.ECDH()
+		if err != nil {
+			return Key{}, err
+		}
+		// The uncompressed point is 0x04 || X || Y
+		point := ecdhKey.Bytes()
+		size := (len(point) - 1) / 2
+		return Key{KeyType: KeyTypeEC, Curve: curve, X: encode(point[1 : 1+size]), Y: encode(point[1+size:])}, nil
+	case ed25519.PublicKey:
+		return Key{KeyType: KeyTypeOKP, Curve: CurveEd25519, X: encode(pub)}, nil
+	case *ecdh.PublicKey:
+		if pub.Curve() != ecdh.X25519() {
+			return Key{}, errors.New("only X25519 ECDH keys are supported, use ECDSA keys for the NIST curves")
+		}
+		return Key{KeyType: KeyTypeOKP, Curve: CurveX25519, X: encode(pub.Bytes())}, nil
+	}
+	return Key{}, fmt.Errorf("unsupported public key %T", publicKey)
+}
+
+// FromPrivateKey converts an *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey or X25519
+// *ecdh.PrivateKey to a JWK holding the private members
+func FromPrivateKey(privateKey crypto.PrivateKey) (Key, error) {
+	switch priv := privateKey.(type) {
+	case *rsa.PrivateKey:
+		if len(priv.Primes) != 2 {
+			return Key{}, errors.New("only RSA keys with two primes are supported")
+		}
+		p, q := priv.Primes[0], priv.Primes[1]
+		one := big.NewInt(1)
+		qInv := new(big.Int).ModInverse(q, p)
+		if qInv == nil {
+			return Key{}, errors.New("RSA primes are invalid")
+		}
+
+		key, _ := FromPublicKey(&priv.PublicKey)
+		key.D = encode(priv.D.Bytes())
+		key.P = encode(p.Bytes())
+		key.Q = encode(q.Bytes())
+		key.DP = encode(new(big.Int).Mod(priv.D, new(big.Int).Sub(p, one)).Bytes())
+		key.DQ = encode(new(big.Int).Mod(priv.D, new(big.Int).Sub(q, one)).Bytes())
+		key.QI = encode(qInv.Bytes())
+		return key, nil
+	case *ecdsa.PrivateKey:
+		key, err := FromPublicKey(&priv.PublicKey)
+		if err != nil {
+			return Key{}, err
+		}
+		ecdhKey, err := priv.ECDH()
+		if err != nil {
+			return Key{}, err
+		}
+		key.D = encode(ecdhKey.Bytes())
+		return key, nil
+	case ed25519.PrivateKey:
+		key, _ := FromPublicKey(priv.Public())
+		key.D = encode(priv.Seed())
+		return key, nil
+	case *ecdh.PrivateKey:
+		key, err := FromPublicKey(priv.PublicKey())
+		if err != nil {
+			return Key{}, err
+		}
+		key.D = encode(priv.Bytes())
+		return key, nil
+	}
+	return Key{}, fmt.Errorf("unsupported private key %T", privateKey)
+}
+
+// IsPrivate returns whether the key holds private members
+func (k Key) IsPrivate() bool {
+	return k.D != ""
+}
+
+// Public returns the key without its private members
+func (k Key) Public() Key {
+	k.D, k.P, k.Q, k.DP, k.DQ, k.QI = "", "", "", "", "", ""
+	k.KeyOps = append([]string(nil), k.KeyOps...)
+	return k
+}
+
+// PublicKey converts the JWK to an *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey or X25519
+// *ecdh.PublicKey
+func (k Key) PublicKey() (crypto.PublicKey, error) {
+	switch k.KeyType {
+	case KeyTypeRSA:
+		n, err := decodeInt("n", k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeInt("e", k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 || e.Bit(0) == 0 {
+			return nil, errors.New("JWK RSA exponent is invalid")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+
+	case KeyTypeEC:
+		curveOID, ok := curveOIDs[k.Curve]
+		if !ok {
+			return nil, fmt.Errorf("unsupported JWK EC curve %q", k.Curve)
+		}
+		point, err := k.ecPoint()
+		if err != nil {
+			return nil, err
+		}
+		oid, _ := asn1.Marshal(curveOID)
+		der, err := asn1.Marshal(subjectPublicKeyInfo{
+			Algorithm: pkix.AlgorithmIdentifier{Algorithm: oidPublicKeyECDSA, Parameters: asn1.RawValue{FullBytes: oid}},
+			PublicKey: asn1.BitString{Bytes: point, BitLength: 8 * len(point)},
+		})
+		if err != nil {
+			return nil, err
+		}
+		pub, err := x509.ParsePKIXPublicKey(der)
+		if err != nil {
+			return nil, fmt.Errorf("JWK EC point is invalid: %w", err)
+		}
+		return pub, nil
+
+	case KeyTypeOKP:
+		x, err := decode("x", k.X)
+		if err != nil {
+			return nil, err
+		}
+		switch k.Curve {
+		case CurveEd25519:
+			if len(x) != ed25519.PublicKeySize {
+				return nil, errors.New("JWK Ed25519 key must be 32 bytes")
+			}
+			return ed25519.PublicKey(x), nil
+		case CurveX25519:
+			return ecdh.X25519().NewPublicKey(x)
+		}
+		return nil, fmt.Errorf("unsupported JWK OKP curve %q", k.Curve)
+	}
+	return nil, fmt.Errorf("unsupported JWK key type %q", k.KeyType)
+}
+
+// PrivateKey converts the JWK to an *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey or
+// X25519 *ecdh.PrivateKey, the private key must match the public members
+func (k Key) PrivateKey() (crypto.PrivateKey, error) {
+	if !k.IsPrivate() {
+		return nil, errors.New("JWK is not a private key")
+	}
+	pub, err := k.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	d, err := decode("d", k.D)
+	if err != nil {
+		return nil, err
+	}
+
+	var priv interface {
+		crypto.PrivateKey
+		Public() crypto.PublicKey
+	}
+	switch k.KeyType {
+	case KeyTypeRSA:
+		if k.P == "" || k.Q == "" {
+			return nil, errors.New("JWK RSA private key must hold the primes p and q")
+		}
+		p, err := decodeInt("p", k.P)
+		if err != nil {
+			return nil, err
+		}
+		q, err := decodeInt("q", k.Q)
+		if err != nil {
+			return nil, err
+		}
+		key := &rsa.PrivateKey{PublicKey: *pub.(*rsa.PublicKey), D: new(big.Int).SetBytes(d), Primes: []*big.Int{p, q}}
+		if err := key.Validate(); err != nil {
+			return nil, err
+		}
+		key.Precompute()
+		priv = key
+
+	case KeyTypeEC:
+		if len(d) != coordinateSizes[k.Curve] {
+			return nil, fmt.Errorf("JWK %s private key must be %d bytes", k.Curve, coordinateSizes[k.Curve])
+		}
+		der, err := asn1.Marshal(ecPrivateKey{Version: 1, PrivateKey: d, NamedCurveOID: curveOIDs[k.Curve]})
+		if err != nil {
+			return nil, err
+		}
+		if priv, err = x509.ParseECPrivateKey(der); err != nil {
+			return nil, fmt.Errorf("JWK EC private key is invalid: %w", err)
+		}
+
+	case KeyTypeOKP:
+		if k.Curve == CurveEd25519 {
+			if len(d) != ed25519.SeedSize {
+				return nil, errors.New("JWK Ed25519 private key must be 32 bytes")
+			}
+			priv = ed25519.NewKeyFromSeed(d)
+		} else if priv, err = ecdh.X25519().NewPrivateKey(d); err != nil {
+			return nil, err
+		}
+	}
+
+	if !pub.(interface{ Equal(crypto.PublicKey) bool }).Equal(priv.Public()) {
+		return nil, errors.New("JWK private key does not match its public members")
+	}
+	return priv, nil
+}
+
+// ecPoint returns the uncompressed point of an EC key, checking the coordinate sizes
+func (k Key) ecPoint() ([]byte, error) {
+	x, err := decode("x", k.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := decode("y", k.Y)
+	if err != nil {
+		return nil, err
+	}
+	if size := coordinateSizes[k.Curve]; len(x) != size || len(y) != size {
+		return nil, fmt.Errorf("JWK %s coordinates must be %d bytes", k.Curve, size)
+	}
+	return append(append([]byte{4}, x...), y...), nil
+}
+
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decode(member string, s string) ([]byte, error) {
+	if s == "" {
+		return nil, fmt.Errorf("JWK has no %s member", member)
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("JWK member %s is not base64url encoded", member)
+	}
+	return b, nil
+}
+
+func decodeInt(member string, s string) (*big.Int, error) {
+	b, err := decode(member, s)
+	if err != nil {
+		return nil, err
+	}
+	n := new(big.Int).SetBytes(b)
+	if n.Sign() == 0 {
+		return nil, fmt.Errorf("JWK member %s is zero", member)
+	}
+	return n, nil
+}
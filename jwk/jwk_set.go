@@ -0,0 +1,84 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package jwk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Set is a JWK Set as per RFC 7517 section 5
+type Set struct {
+	Keys []Key `json:"keys"`
+}
+
+// ParseSet decodes a JWK Set, every key must have a key type and the key IDs must be unique
+func ParseSet(data []byte) (Set, error) {
+	var set Set
+	if err := json.Unmarshal(data, &set); err != nil {
+		return Set{}, err
+	}
+	if set.Keys == nil {
+		return Set{}, errors.New("JWK Set has no keys member")
+	}
+
+	keyIDs := make(map[string]bool, len(set.Keys))
+	for i, key := range set.Keys {
+		if key.KeyType == "" {
+			return Set{}, fmt.Errorf("JWK %d of the set has no kty member", i)
+		}
+		if key.KeyID != "" {
+			if keyIDs[key.KeyID] {
+				return Set{}, fmt.Errorf("JWK Set has the key ID %q twice", key.KeyID)
+			}
+			keyIDs[key.KeyID] = true
+		}
+	}
+	return set, nil
+}
+
+// Add appends the key, its ID is set to its thumbprint when it has none
+func (s *Set) Add(key Key) error {
+	if key.KeyID == "" {
+		thumbprint, err := key.Thumbprint()
+		if err != nil {
+			return err
+		}
+		key.KeyID = thumbprint
+	}
+	if _, exists := s.Key(key.KeyID); exists {
+		return fmt.Errorf("JWK Set already has the key ID %q", key.KeyID)
+	}
+	s.Keys = append(s.Keys, key)
+	return nil
+}
+
+// Key returns the key with the ID
+func (s Set) Key(keyID string) (Key, bool) {
+	for _, key := range s.Keys {
+		if key.KeyID == keyID {
+			return key, true
+		}
+	}
+	return Key{}, false
+}
+
+// Public returns the set without the private members of its keys, ready to be published
+func (s Set) Public() Set {
+	public := Set{Keys: make([]Key, len(s.Keys))}
+	for i, key := range s.Keys {
+		public.Keys[i] = key.Public()
+	}
+	return public
+}
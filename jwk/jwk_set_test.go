@@ -0,0 +1,71 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package jwk
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"testing"
+)
+
+func TestSet(t *testing.T) {
+	rsaKey, _ := Parse([]byte(rfc7638Key))
+	_, ed25519Key, _ := ed25519.GenerateKey(rand.Reader)
+	okpKey, _ := FromPrivateKey(ed25519Key)
+
+	var set Set
+	if err := set.Add(rsaKey); err != nil {
+		t.Fatal("Did not expect an error", err)
+	}
+	if err := set.Add(okpKey); err != nil {
+		t.Fatal("Did not expect an error", err)
+	}
+	if err := set.Add(rsaKey); err == nil {
+		t.Fatal("Expected an error for a key ID added twice")
+	}
+
+	thumbprint, _ := okpKey.Thumbprint()
+	if key, ok := set.Key(thumbprint); !ok || !key.IsPrivate() {
+		t.Fatal("Expected the key to be found by its thumbprint")
+	}
+	if _, ok := set.Key("2011-04-29"); !ok {
+		t.Fatal("Expected the key to be found by its ID")
+	}
+
+	data, _ := json.Marshal(set.Public())
+	parsed, err := ParseSet(data)
+	if err != nil || len(parsed.Keys) != 2 {
+		t.Fatal("Expected the set to round trip", err)
+	}
+	for _, key := range parsed.Keys {
+		if key.IsPrivate() {
+			t.Fatalf("Expected the published key %s to be public", key.KeyID)
+		}
+	}
+	if key, _ := set.Key(thumbprint); !key.IsPrivate() {
+		t.Fatal("Expected Public to leave the set unchanged")
+	}
+}
+
+func TestParseSetErrors(t *testing.T) {
+	for _, data := range []string{
+		`{}`,
+		`{"keys":[{"kid":"1"}]}`,
+		`{"keys":[{"kty":"RSA","kid":"1"},{"kty":"EC","kid":"1"}]}`,
+		`[]`,
+	} {
+		if _, err := ParseSet([]byte(data)); err == nil {
+			t.Errorf("Expected an error for %s", data)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package jwk
+
+import (
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// RFC 7517 appendix A.1 and A.2
+const rfc7517ECKey = `{"kty":"EC","crv":"P-256","use":"enc","kid":"1",
+	"x":"MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4",
+	"y":"4Etl6SRW2YiLUrN5vfvVHuhp7x8PxltmWWlbbM4IFyM",
+	"d":"870MB6gfuTJ4HtUnUvYMyJpr5eUZNP4Bk43bVdj3eAE"}`
+
+func TestParseRFCKeys(t *testing.T) {
+	for _, data := range []string{rfc7517ECKey, rfc8037Key} {
+		key, err := Parse([]byte(data))
+		if err != nil {
+			t.Fatal("Did not expect an error", err)
+		}
+		priv, err := key.PrivateKey()
+		if err != nil {
+			t.Fatal("Expected the private key to match its public members", err)
+		}
+
+		// Exporting the parsed key gives the same members back
+		exported, err := FromPrivateKey(priv)
+		if err != nil {
+			t.Fatal("Did not expect an error", err)
+		}
+		if exported.X != key.X || exported.Y != key.Y || exported.D != key.D || exported.Curve != key.Curve {
+			t.Errorf("Expected %+v to round trip but got %+v", key, exported)
+		}
+	}
+
+	rsaKey, _ := Parse([]byte(rfc7638Key))
+	pub, err := rsaKey.PublicKey()
+	if err != nil || pub.(*rsa.PublicKey).E != 65537 || pub.(*rsa.PublicKey).N.BitLen() != 2048 {
+		t.Fatal("Expected a 2048 bits RSA public key", err)
+	}
+}
+
+func TestPrivateKeyRoundTrip(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	p256, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	p384, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	p521, _ := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	_, ed25519Key, _ := ed25519.GenerateKey(rand.Reader)
+	x25519Key, _ := ecdh.X25519().GenerateKey(rand.Reader)
+
+	for _, priv := range []interface {
+		Public() crypto.PublicKey
+		Equal(crypto.PrivateKey) bool
+	}{rsaKey, p256, p384, p521, ed25519Key, x25519Key} {
+		key, err := FromPrivateKey(priv)
+		if err != nil {
+			t.Fatalf("Did not expect an error for %T but got %q", priv, err)
+		}
+		data, _ := json.Marshal(key)
+		parsed, err := Parse(data)
+		if err != nil {
+			t.Fatalf("Did not expect an error for %T but got %q", priv, err)
+		}
+
+		decoded, err := parsed.PrivateKey()
+		if err != nil || !priv.Equal(decoded) {
+			t.Errorf("Expected the private %T to round trip (%v)", priv, err)
+		}
+
+		public := parsed.Public()
+		if public.IsPrivate() || strings.Contains(mustMarshal(public), `"d"`) {
+			t.Errorf("Expected the public %T JWK to have no private member", priv)
+		}
+		decodedPublic, err := public.PublicKey()
+		if err != nil || !decodedPublic.(interface{ Equal(crypto.PublicKey) bool }).Equal(priv.Public()) {
+			t.Errorf("Expected the public %T to round trip (%v)", priv, err)
+		}
+
+		fromPublic, _ := FromPublicKey(priv.Public())
+		if mustMarshal(fromPublic) != mustMarshal(public) {
+			t.Errorf("Expected the JWK of the public %T to match the public members", priv)
+		}
+	}
+}
+
+func TestInvalidKeys(t *testing.T) {
+	ecKey, _ := Parse([]byte(rfc7517ECKey))
+	okpKey, _ := Parse([]byte(rfc8037Key))
+
+	mismatch := ecKey
+	mismatch.D = "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAE"
+	short := ecKey
+	short.X = short.X[1:]
+	offCurve := ecKey
+	offCurve.Y = offCurve.X
+	otherSeed := okpKey
+	otherSeed.D = okpKey.X
+	noPrimes := Key{KeyType: KeyTypeRSA, N: "AQAB", E: "AQAB", D: "AQAB"}
+
+	for name, key := range map[string]Key{
+		"mismatch": mismatch, "short": short, "off curve": offCurve, "other seed": otherSeed, "no primes": noPrimes,
+	} {
+		if _, err := key.PrivateKey(); err == nil {
+			t.Errorf("Expected an error for the %s key", name)
+		}
+	}
+
+	if _, err := okpKey.Public().PrivateKey(); err == nil {
+		t.Error("Expected an error for a public key")
+	}
+	if _, err := Parse([]byte(`{"crv":"P-256"}`)); err == nil {
+		t.Error("Expected an error without kty")
+	}
+	if _, err := (Key{KeyType: "oct"}).PublicKey(); err == nil {
+		t.Error("Expected an error for a symmetric key")
+	}
+	if _, err := (Key{KeyType: KeyTypeRSA, N: "AQAB", E: "Ag"}).PublicKey(); err == nil {
+		t.Error("Expected an error for an even exponent")
+	}
+}
+
+func mustMarshal(key Key) string {
+	data, _ := json.Marshal(key)
+	return string(data)
+}
@@ -0,0 +1,80 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package jwk
+
+import (
+	"crypto"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/json"
+	"fmt"
+)
+
+// The required members of every key type, in the lexicographic order of RFC 7638
+type rsaThumbprintMembers struct {
+	E       string `json:"e"`
+	KeyType string `json:"kty"`
+	N       string `json:"n"`
+}
+
+type ecThumbprintMembers struct {
+	Curve   string `json:"crv"`
+	KeyType string `json:"kty"`
+	X       string `json:"x"`
+	Y       string `json:"y"`
+}
+
+type okpThumbprintMembers struct {
+	Curve   string `json:"crv"`
+	KeyType string `json:"kty"`
+	X       string `json:"x"`
+}
+
+// Thumbprint returns the base64url encoded RFC 7638 SHA-256 thumbprint of the key, which is the
+// same for the public and the private key
+func (k Key) Thumbprint() (string, error) {
+	thumbprint, err := k.ThumbprintBytes(crypto.SHA256)
+	if err != nil {
+		return "", err
+	}
+	return encode(thumbprint), nil
+}
+
+// ThumbprintBytes returns the RFC 7638 thumbprint of the key with the hash
+func (k Key) ThumbprintBytes(hash crypto.Hash) ([]byte, error) {
+	if !hash.Available() {
+		return nil, fmt.Errorf("hash %s is not available", hash)
+	}
+	// The members are checked so that a thumbprint always identifies a valid key
+	if _, err := k.PublicKey(); err != nil {
+		return nil, err
+	}
+
+	var members interface{}
+	switch k.KeyType {
+	case KeyTypeRSA:
+		members = rsaThumbprintMembers{k.E, k.KeyType, k.N}
+	case KeyTypeEC:
+		members = ecThumbprintMembers{k.Curve, k.KeyType, k.X, k.Y}
+	case KeyTypeOKP:
+		members = okpThumbprintMembers{k.Curve, k.KeyType, k.X}
+	}
+	encoded, err := json.Marshal(members)
+	if err != nil {
+		return nil, err
+	}
+
+	h := hash.New()
+	h.Write(encoded)
+	return h.Sum(nil), nil
+}
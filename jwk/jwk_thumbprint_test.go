@@ -0,0 +1,67 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package jwk
+
+import (
+	"bytes"
+	"crypto"
+	"testing"
+)
+
+// RFC 7638 section 3.1
+const rfc7638Key = `{"kty":"RSA","alg":"RS256","kid":"2011-04-29","e":"AQAB",
+	"n":"0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw"}`
+
+// RFC 8037 appendix A.1
+const rfc8037Key = `{"kty":"OKP","crv":"Ed25519",
+	"d":"nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A",
+	"x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`
+
+func TestThumbprint(t *testing.T) {
+	for _, tc := range []struct {
+		key, expected string
+	}{
+		{rfc7638Key, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs"},
+		// RFC 8037 appendix A.3, the private member is ignored
+		{rfc8037Key, "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k"},
+	} {
+		key, err := Parse([]byte(tc.key))
+		if err != nil {
+			t.Fatal("Did not expect an error", err)
+		}
+		thumbprint, err := key.Thumbprint()
+		if err != nil || thumbprint != tc.expected {
+			t.Errorf("Expected the thumbprint %s but got %s (%v)", tc.expected, thumbprint, err)
+		}
+	}
+}
+
+func TestThumbprintBytes(t *testing.T) {
+	key, _ := Parse([]byte(rfc7638Key))
+
+	// RFC 7638 section 3.1 lists the octets of the SHA-256 thumbprint
+	thumbprint, err := key.ThumbprintBytes(crypto.SHA256)
+	expected := []byte{55, 54, 203, 177, 120, 124, 184, 48, 156, 119, 238, 140, 55, 5, 197, 225,
+		111, 251, 158, 133, 151, 21, 144, 31, 30, 76, 89, 177, 17, 130, 245, 123}
+	if err != nil || !bytes.Equal(thumbprint, expected) {
+		t.Fatalf("Expected the thumbprint %x but got %x (%v)", expected, thumbprint, err)
+	}
+
+	if thumbprint, _ := key.ThumbprintBytes(crypto.SHA512); len(thumbprint) != 64 {
+		t.Fatal("Expected a SHA-512 thumbprint")
+	}
+
+	key.N = "AQAB!"
+	if _, err := key.Thumbprint(); err == nil {
+		t.Fatal("Expected an error for an invalid key")
+	}
+}
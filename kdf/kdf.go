@@ -0,0 +1,40 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+// Package kdf derives keys from a master key with HKDF (RFC 5869) or the NIST SP 800-108 KDFs, and
+// derives a hierarchy of keys by path so that only one root key has to be stored per environment
+package kdf
+
+import (
+	"crypto/sha256"
+	"errors"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// maxHKDFLength is the RFC 5869 output limit of 255 SHA-256 blocks
+const maxHKDFLength = 255 * sha256.Size
+
+// HKDF derives length bytes from the input keying material with HKDF-SHA256, the salt is optional
+// and the info binds the output to its purpose
+func HKDF(secret []byte, salt []byte, info []byte, length int) ([]byte, error) {
+	if length <= 0 || length > maxHKDFLength {
+		return nil, errors.New("HKDF output length must be between 1 and 8160 bytes")
+	}
+
+	out := make([]byte, length)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, info), out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,103 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package kdf
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strings"
+
+	"github.com/exohood/exohood-crypto-algorithms/aes"
+	"github.com/exohood/exohood-crypto-algorithms/des"
+)
+
+const (
+	minRootKeyLength = 32
+	nodeKeyLength    = 32
+	pathSeparator    = "/"
+)
+
+// The contexts separate the intermediate keys from the keys of every algorithm, so the same path
+// never gives related AES and 3DES keys
+var (
+	nodeContext      = []byte("exohood kdf node")
+	rawKeyContext    = []byte("exohood kdf key")
+	aesKeyContext    = []byte("exohood kdf aes")
+	tripleDESContext = []byte("exohood kdf 3des")
+)
+
+// Hierarchy derives deterministic keys from a root key by path, e.g. root/tenant-42/pan-encryption/v3.
+// Every component of the path derives a child key from its parent with the NIST SP 800-108
+// counter mode KDF over HMAC-SHA256, the component being the label. Knowing a derived key does not
+// reveal its parent or its siblings.
+type Hierarchy struct {
+	rootKey []byte
+}
+
+// NewHierarchy constructs a key hierarchy from the root key, which must be at least 32 bytes
+func NewHierarchy(rootKey []byte) (*Hierarchy, error) {
+	if len(rootKey) < minRootKeyLength {
+		return nil, fmt.Errorf("root key must be at least %d bytes", minRootKeyLength)
+	}
+	return &Hierarchy{append([]byte(nil), rootKey...)}, nil
+}
+
+// DeriveKey derives length raw bytes for the path
+func (h *Hierarchy) DeriveKey(path string, length int) ([]byte, error) {
+	return h.derive(path, rawKeyContext, length)
+}
+
+// DeriveAES derives an AES GCM cipher for the path, the key size must be either 16, 24, or 32 bytes
+func (h *Hierarchy) DeriveAES(path string, keySize int, opts ...aes.Option) (aes.Cipher, error) {
+	if keySize != 16 && keySize != 24 && keySize != 32 {
+		return aes.Cipher{}, errors.New("AES key must be either 16, 24 or 32 bytes")
+	}
+
+	keyBytes, err := h.derive(path, aesKeyContext, keySize)
+	if err != nil {
+		return aes.Cipher{}, err
+	}
+	return aes.New(keyBytes, opts...)
+}
+
+// DeriveTripleDES derives a three key 3DES cipher for the path, the key bytes have odd parity
+func (h *Hierarchy) DeriveTripleDES(path string, opts ...des.Option) (des.Cipher, error) {
+	keyBytes, err := h.derive(path, tripleDESContext, 24)
+	if err != nil {
+		return des.Cipher{}, err
+	}
+	for i, b := range keyBytes {
+		keyBytes[i] = b&0xfe | byte(bits.OnesCount8(b&0xfe)+1)&1
+	}
+	return des.CreateFromTripleDESKeyBytes(keyBytes, opts...)
+}
+
+// derive walks the path from the root key, the last component derives the key with the context
+func (h *Hierarchy) derive(path string, context []byte, length int) ([]byte, error) {
+	components := strings.Split(path, pathSeparator)
+	for _, component := range components {
+		if component == "" {
+			return nil, fmt.Errorf("path %q has an empty component", path)
+		}
+	}
+
+	node := h.rootKey
+	for _, component := range components[:len(components)-1] {
+		var err error
+		if node, err = CounterMode(HMACSHA256, node, []byte(component), nodeContext, nodeKeyLength); err != nil {
+			return nil, err
+		}
+	}
+	return CounterMode(HMACSHA256, node, []byte(components[len(components)-1]), context, length)
+}
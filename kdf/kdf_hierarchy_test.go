@@ -0,0 +1,93 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package kdf
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hashicorp/go-uuid"
+)
+
+func TestHierarchy(t *testing.T) {
+	rootKey, _ := uuid.GenerateRandomBytes(32)
+	hierarchy, err := NewHierarchy(rootKey)
+	if err != nil {
+		t.Fatalf("Did not expect an error but got %q", err)
+	}
+
+	key, _ := hierarchy.DeriveKey("root/tenant-42/pan-encryption/v3", 32)
+	again, _ := hierarchy.DeriveKey("root/tenant-42/pan-encryption/v3", 32)
+	if !bytes.Equal(key, again) {
+		t.Error("Expected the derivation to be deterministic")
+	}
+
+	for _, path := range []string{"root/tenant-43/pan-encryption/v3", "root/tenant-42/pan-encryption/v4", "root/tenant-42/pan-encryption"} {
+		other, _ := hierarchy.DeriveKey(path, 32)
+		if bytes.Equal(key, other) {
+			t.Errorf("Expected a different key for %s", path)
+		}
+	}
+	if short, _ := hierarchy.DeriveKey("root/tenant-42/pan-encryption/v3", 16); bytes.Equal(short, key[:16]) {
+		t.Error("Expected the key length to be part of the derivation")
+	}
+
+	for _, path := range []string{"", "root//v3", "root/tenant-42/"} {
+		if _, err := hierarchy.DeriveKey(path, 32); err == nil {
+			t.Errorf("should be an error for path %q", path)
+		}
+	}
+	if _, err := NewHierarchy(rootKey[:16]); err == nil {
+		t.Error("should be an error if the root key is too short")
+	}
+}
+
+func TestHierarchy_Ciphers(t *testing.T) {
+	rootKey, _ := uuid.GenerateRandomBytes(32)
+	hierarchy, _ := NewHierarchy(rootKey)
+
+	aesCipher, err := hierarchy.DeriveAES("root/tenant-42/pan-encryption/v3", 32)
+	if err != nil {
+		t.Fatalf("Did not expect an error but got %q", err)
+	}
+	cipherBytes, _, _ := aesCipher.Encrypt([]byte("my secret 1234"), true)
+	sameCipher, _ := hierarchy.DeriveAES("root/tenant-42/pan-encryption/v3", 32)
+	plainBytes, err := sameCipher.Decrypt(cipherBytes, nil)
+	if err != nil || string(plainBytes) != "my secret 1234" {
+		t.Errorf("Expected my secret 1234 but got %s (%v)", plainBytes, err)
+	}
+	if _, err := hierarchy.DeriveAES("root/tenant-42/pan-encryption/v3", 20); err == nil {
+		t.Error("should be an error if the AES key size is invalid")
+	}
+
+	desCipher, err := hierarchy.DeriveTripleDES("root/tenant-42/pin-translation/v1")
+	if err != nil {
+		t.Fatalf("Did not expect an error but got %q", err)
+	}
+	if len(desCipher.KeyBytes) != 24 {
+		t.Errorf("Expected a 24 bytes 3DES key but got %d bytes", len(desCipher.KeyBytes))
+	}
+	for _, b := range desCipher.KeyBytes {
+		ones := 0
+		for ; b != 0; b &= b - 1 {
+			ones++
+		}
+		if ones%2 != 1 {
+			t.Fatalf("Expected odd parity for every key byte of %x", desCipher.KeyBytes)
+		}
+	}
+
+	sameLengthAES, _ := hierarchy.DeriveAES("root/tenant-42/pin-translation/v1", 24)
+	if bytes.Equal(sameLengthAES.KeyBytes, desCipher.KeyBytes) {
+		t.Error("Expected the AES and 3DES keys of the same path to differ")
+	}
+}
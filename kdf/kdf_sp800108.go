@@ -0,0 +1,94 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package kdf
+
+import (
+	"crypto/aes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"math"
+
+	"github.com/exohood/exohood-crypto-algorithms/cmac"
+)
+
+// PRF constructs the pseudorandom function of the NIST SP 800-108 KDFs keyed with the key
+type PRF func(key []byte) (hash.Hash, error)
+
+// HMACSHA256 is the HMAC-SHA256 PRF, it accepts keys of any length
+func HMACSHA256(key []byte) (hash.Hash, error) {
+	return hmac.New(sha256.New, key), nil
+}
+
+// CMACAES is the AES-CMAC PRF, the key must be either 16, 24, or 32 bytes
+func CMACAES(key []byte) (hash.Hash, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cmac.New(block)
+}
+
+// CounterMode is the NIST SP 800-108 KDF in counter mode, every block is
+// PRF(key, [i]_32 || label || 0x00 || context || [L]_32) where L is the output length in bits
+func CounterMode(prf PRF, key []byte, label []byte, context []byte, length int) ([]byte, error) {
+	return sp800108(prf, key, nil, label, context, length)
+}
+
+// FeedbackMode is the NIST SP 800-108 KDF in feedback mode, every block is
+// PRF(key, K(i-1) || [i]_32 || label || 0x00 || context || [L]_32) with K(0) = iv, the iv may be
+// empty
+func FeedbackMode(prf PRF, key []byte, iv []byte, label []byte, context []byte, length int) ([]byte, error) {
+	if iv == nil {
+		iv = []byte{}
+	}
+	return sp800108(prf, key, iv, label, context, length)
+}
+
+// sp800108 runs the counter mode if the iv is nil, or the feedback mode otherwise
+func sp800108(prf PRF, key []byte, iv []byte, label []byte, context []byte, length int) ([]byte, error) {
+	if length <= 0 || uint64(length)*8 > math.MaxUint32 {
+		return nil, errors.New("KDF output length must be positive and below 2^32 bits")
+	}
+
+	mac, err := prf(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var counter, bits [4]byte
+	binary.BigEndian.PutUint32(bits[:], uint32(length*8))
+
+	out := make([]byte, 0, length)
+	previous := iv
+	for i := uint32(1); len(out) < length; i++ {
+		binary.BigEndian.PutUint32(counter[:], i)
+
+		mac.Reset()
+		mac.Write(previous)
+		mac.Write(counter[:])
+		mac.Write(label)
+		mac.Write([]byte{0x00})
+		mac.Write(context)
+		mac.Write(bits[:])
+
+		block := mac.Sum(nil)
+		out = append(out, block...)
+		if iv != nil {
+			previous = block
+		}
+	}
+	return out[:length], nil
+}
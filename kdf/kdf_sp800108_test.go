@@ -0,0 +1,47 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package kdf
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// The expected values are computed with the OpenSSL KBKDF, e.g.
+// openssl kdf -keylen 42 -kdfopt mac:HMAC -kdfopt digest:SHA256 -kdfopt hexkey:... -kdfopt salt:label -kdfopt info:context KBKDF
+func TestSP800108(t *testing.T) {
+	hmacKey, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+	cmacKey, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+	iv, _ := hex.DecodeString("f0f1f2f3f4f5f6f7f8f9fafbfcfdfefff0f1f2f3f4f5f6f7f8f9fafbfcfdfeff")
+
+	counterHMAC, _ := CounterMode(HMACSHA256, hmacKey, []byte("label"), []byte("context"), 42)
+	if hex.EncodeToString(counterHMAC) != "b9cd5f6323f01f4680650855f1ebea9b4c54c08131b506fc28c856364a38a2f4fb680c12ea51696887d9" {
+		t.Errorf("Unexpected counter mode HMAC output %x", counterHMAC)
+	}
+
+	feedbackHMAC, _ := FeedbackMode(HMACSHA256, hmacKey, iv, []byte("label"), []byte("context"), 42)
+	if hex.EncodeToString(feedbackHMAC) != "3a9dc405df8624f38c1d97d33e88a92702f5a45ff3e5bba8f877bf8205d37a2d0e5d15406ef3920bd614" {
+		t.Errorf("Unexpected feedback mode HMAC output %x", feedbackHMAC)
+	}
+
+	counterCMAC, _ := CounterMode(CMACAES, cmacKey, []byte("label"), []byte("context"), 40)
+	if hex.EncodeToString(counterCMAC) != "a300fcb765b39d16a5b4b1e32f812765bbd0ebc9f403627dec52879d8bd3d7346c88d24ac7f0ab30" {
+		t.Errorf("Unexpected counter mode CMAC output %x", counterCMAC)
+	}
+
+	if _, err := CounterMode(CMACAES, cmacKey[:10], []byte("label"), nil, 16); err == nil {
+		t.Error("should be an error if the CMAC key size is invalid")
+	}
+	if _, err := CounterMode(HMACSHA256, hmacKey, []byte("label"), nil, 0); err == nil {
+		t.Error("should be an error if the output length is not positive")
+	}
+}
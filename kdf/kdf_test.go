@@ -0,0 +1,35 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package kdf
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// RFC 5869, appendix A.1
+func TestHKDF(t *testing.T) {
+	secret, _ := hex.DecodeString(strings.Repeat("0b", 22))
+	salt, _ := hex.DecodeString("000102030405060708090a0b0c")
+	info, _ := hex.DecodeString("f0f1f2f3f4f5f6f7f8f9")
+
+	key, err := HKDF(secret, salt, info, 42)
+	expected := "3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf34007208d5b887185865"
+	if err != nil || hex.EncodeToString(key) != expected {
+		t.Errorf("Expected %s but got %x (%v)", expected, key, err)
+	}
+
+	if _, err := HKDF(secret, salt, info, 255*32+1); err == nil {
+		t.Error("should be an error if the output length is above the RFC 5869 limit")
+	}
+}
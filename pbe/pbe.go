@@ -0,0 +1,210 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+// Package pbe encrypts data under a passphrase. The output is a self-describing header, holding
+// the KDF, its cost parameters and the salt, followed by the AES-256-GCM output of the aes
+// package, so the parameters can be raised over time without breaking existing exports.
+package pbe
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/exohood/exohood-crypto-algorithms/aes"
+)
+
+const (
+	formatVersion = 1
+	keyLength     = 32
+	saltLength    = 16
+	minSaltLength = 16
+	// gcmOverhead is the prefixed nonce and the tag of the aes.Cipher output
+	gcmOverhead = 12 + 16
+)
+
+var (
+	magic = []byte("EXPB")
+
+	// ErrInvalidFormat is returned when the input is not a PBE encrypted payload
+	ErrInvalidFormat = errors.New("invalid PBE format")
+)
+
+// header is the parsed self-describing header
+type header struct {
+	params Params
+	salt   []byte
+}
+
+// DeriveCipher derives an AES-256-GCM cipher from the passphrase and the salt, the parameters
+// must be above the minimum costs
+func DeriveCipher(passphrase []byte, salt []byte, params Params) (aes.Cipher, error) {
+	if err := params.Validate(); err != nil {
+		return aes.Cipher{}, err
+	}
+	if len(salt) < minSaltLength {
+		return aes.Cipher{}, fmt.Errorf("salt must be at least %d bytes", minSaltLength)
+	}
+
+	keyBytes, err := params.deriveKey(passphrase, salt, keyLength)
+	if err != nil {
+		return aes.Cipher{}, err
+	}
+	return aes.New(keyBytes)
+}
+
+// Encrypt encrypts the plain bytes under the passphrase with a random salt, the parameters are
+// stored in the header of the output
+func Encrypt(passphrase []byte, plainBytes []byte, params Params) ([]byte, error) {
+	salt := make([]byte, saltLength)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, fmt.Errorf("fail to generate salt: %w", err)
+	}
+
+	cipher, err := DeriveCipher(passphrase, salt, params)
+	if err != nil {
+		return nil, err
+	}
+
+	cipherBytes, _, err := cipher.Encrypt(plainBytes, true)
+	if err != nil {
+		return nil, err
+	}
+	return append(marshalHeader(header{params, salt}), cipherBytes...), nil
+}
+
+// Decrypt decrypts the output of Encrypt with the passphrase, using the parameters of its header
+func Decrypt(passphrase []byte, encrypted []byte) ([]byte, error) {
+	h, cipherBytes, err := parseHeader(encrypted)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cipherBytes) < gcmOverhead {
+		return nil, ErrInvalidFormat
+	}
+
+	cipher, err := DeriveCipher(passphrase, h.salt, h.params)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.Decrypt(cipherBytes, nil)
+}
+
+// ReadParams returns the parameters stored in the header without decrypting
+func ReadParams(encrypted []byte) (Params, error) {
+	h, _, err := parseHeader(encrypted)
+	return h.params, err
+}
+
+// NeedsUpgrade returns whether the payload was encrypted with another KDF or with any cost below
+// the target parameters
+func NeedsUpgrade(encrypted []byte, target Params) (bool, error) {
+	params, err := ReadParams(encrypted)
+	if err != nil {
+		return false, err
+	}
+	return params.weakerThan(target), nil
+}
+
+// Reencrypt decrypts the payload and encrypts it again with the target parameters and a fresh
+// salt, typically after NeedsUpgrade returned true
+func Reencrypt(passphrase []byte, encrypted []byte, target Params) ([]byte, error) {
+	plainBytes, err := Decrypt(passphrase, encrypted)
+	if err != nil {
+		return nil, err
+	}
+	return Encrypt(passphrase, plainBytes, target)
+}
+
+// marshalHeader encodes magic || version || KDF || parameters || salt length || salt, the
+// integers are big endian
+func marshalHeader(h header) []byte {
+	out := append(append([]byte(nil), magic...), formatVersion, byte(h.params.KDF))
+	switch h.params.KDF {
+	case Argon2id:
+		out = binary.BigEndian.AppendUint32(out, h.params.Time)
+		out = binary.BigEndian.AppendUint32(out, h.params.MemoryKiB)
+		out = append(out, h.params.Threads)
+	case Scrypt:
+		out = append(out, h.params.LogN)
+		out = binary.BigEndian.AppendUint32(out, h.params.R)
+		out = binary.BigEndian.AppendUint32(out, h.params.P)
+	case PBKDF2SHA256:
+		out = binary.BigEndian.AppendUint32(out, h.params.Iterations)
+	}
+	out = append(out, byte(len(h.salt)))
+	return append(out, h.salt...)
+}
+
+// parseHeader decodes the header and returns the remaining cipher bytes
+func parseHeader(encrypted []byte) (header, []byte, error) {
+	in := encrypted
+	next := func(n int) []byte {
+		if len(in) < n {
+			return nil
+		}
+		field := in[:n]
+		in = in[n:]
+		return field
+	}
+
+	prefix := next(len(magic) + 2)
+	if prefix == nil || !bytes.Equal(prefix[:len(magic)], magic) {
+		return header{}, nil, ErrInvalidFormat
+	}
+	if prefix[len(magic)] != formatVersion {
+		return header{}, nil, fmt.Errorf("unsupported PBE format version %d", prefix[len(magic)])
+	}
+
+	params := Params{KDF: KDF(prefix[len(magic)+1])}
+	switch params.KDF {
+	case Argon2id:
+		field := next(9)
+		if field == nil {
+			return header{}, nil, ErrInvalidFormat
+		}
+		params.Time = binary.BigEndian.Uint32(field)
+		params.MemoryKiB = binary.BigEndian.Uint32(field[4:])
+		params.Threads = field[8]
+	case Scrypt:
+		field := next(9)
+		if field == nil {
+			return header{}, nil, ErrInvalidFormat
+		}
+		params.LogN = field[0]
+		params.R = binary.BigEndian.Uint32(field[1:])
+		params.P = binary.BigEndian.Uint32(field[5:])
+	case PBKDF2SHA256:
+		field := next(4)
+		if field == nil {
+			return header{}, nil, ErrInvalidFormat
+		}
+		params.Iterations = binary.BigEndian.Uint32(field)
+	default:
+		return header{}, nil, fmt.Errorf("unknown KDF %d", params.KDF)
+	}
+
+	saltSize := next(1)
+	if saltSize == nil {
+		return header{}, nil, ErrInvalidFormat
+	}
+	salt := next(int(saltSize[0]))
+	if salt == nil {
+		return header{}, nil, ErrInvalidFormat
+	}
+
+	return header{params, salt}, in, nil
+}
@@ -0,0 +1,149 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package pbe
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// KDF identifies the password-based key derivation function, the value is stored in the header
+type KDF byte
+
+const (
+	// Argon2id is the RFC 9106 memory hard function, the recommended choice
+	Argon2id KDF = iota + 1
+	// Scrypt is the RFC 7914 memory hard function
+	Scrypt
+	// PBKDF2SHA256 is PBKDF2 (RFC 8018) with HMAC-SHA256, for FIPS environments
+	PBKDF2SHA256
+)
+
+// Minimum costs accepted when encrypting and decrypting, they follow the OWASP password storage
+// recommendations. Maximum costs bound the memory and the work an untrusted header can ask for.
+const (
+	MinArgon2idTime      = 2
+	MinArgon2idMemoryKiB = 19 * 1024
+	MinScryptLogN        = 15
+	MinScryptR           = 8
+	MinPBKDF2Iterations  = 310000
+
+	maxArgon2idTime      = 10
+	maxArgon2idMemoryKiB = 1024 * 1024
+	maxScryptMemory      = 1 << 30
+	maxScryptLogN        = 20
+	maxScryptP           = 16
+	maxPBKDF2Iterations  = 100000000
+)
+
+// Params are the KDF and its cost parameters, only the fields of the selected KDF are used
+type Params struct {
+	KDF KDF
+
+	// Argon2id passes, memory in KiB and parallelism
+	Time      uint32
+	MemoryKiB uint32
+	Threads   uint8
+
+	// scrypt cost N = 2^LogN, block size and parallelism
+	LogN uint8
+	R    uint32
+	P    uint32
+
+	// PBKDF2 iterations
+	Iterations uint32
+}
+
+// DefaultParams returns the default cost parameters of the KDF
+func DefaultParams(kdf KDF) Params {
+	switch kdf {
+	case Argon2id:
+		return Params{KDF: Argon2id, Time: 3, MemoryKiB: 64 * 1024, Threads: 4}
+	case Scrypt:
+		return Params{KDF: Scrypt, LogN: 17, R: 8, P: 1}
+	case PBKDF2SHA256:
+		return Params{KDF: PBKDF2SHA256, Iterations: 600000}
+	}
+	return Params{KDF: kdf}
+}
+
+// Validate checks the parameters are within the minimum and maximum costs of the KDF
+func (p Params) Validate() error {
+	switch p.KDF {
+	case Argon2id:
+		if p.Time < MinArgon2idTime || p.MemoryKiB < MinArgon2idMemoryKiB || p.Threads == 0 {
+			return fmt.Errorf("Argon2id costs must be at least t=%d, m=%dKiB and p=1",
+				MinArgon2idTime, MinArgon2idMemoryKiB)
+		}
+		if p.Time > maxArgon2idTime || p.MemoryKiB > maxArgon2idMemoryKiB {
+			return errors.New("Argon2id costs are too high")
+		}
+	case Scrypt:
+		if p.LogN < MinScryptLogN || p.R < MinScryptR || p.P == 0 {
+			return fmt.Errorf("scrypt costs must be at least N=2^%d, r=%d and p=1", MinScryptLogN, MinScryptR)
+		}
+		if p.LogN > maxScryptLogN || p.P > maxScryptP || scryptMemory(p) > maxScryptMemory {
+			return errors.New("scrypt costs are too high")
+		}
+	case PBKDF2SHA256:
+		if p.Iterations < MinPBKDF2Iterations {
+			return fmt.Errorf("PBKDF2 iterations must be at least %d", MinPBKDF2Iterations)
+		}
+		if p.Iterations > maxPBKDF2Iterations {
+			return errors.New("PBKDF2 iterations are too high")
+		}
+	default:
+		return fmt.Errorf("unknown KDF %d", p.KDF)
+	}
+	return nil
+}
+
+// scryptMemory returns the bytes allocated by scrypt: the 128·r·N table, the 128·r·p blocks and
+// the 256·r working buffer
+func scryptMemory(p Params) uint64 {
+	return 128 * uint64(p.R) * ((uint64(1) << p.LogN) + uint64(p.P) + 2)
+}
+
+// weakerThan returns whether the parameters use another KDF or any lower cost than the target
+func (p Params) weakerThan(target Params) bool {
+	if p.KDF != target.KDF {
+		return true
+	}
+
+	switch p.KDF {
+	case Argon2id:
+		return p.Time < target.Time || p.MemoryKiB < target.MemoryKiB || p.Threads < target.Threads
+	case Scrypt:
+		return p.LogN < target.LogN || p.R < target.R || p.P < target.P
+	default:
+		return p.Iterations < target.Iterations
+	}
+}
+
+// deriveKey runs the KDF over the passphrase and the salt, the parameters must be valid
+func (p Params) deriveKey(passphrase []byte, salt []byte, keyLength int) ([]byte, error) {
+	switch p.KDF {
+	case Argon2id:
+		return argon2.IDKey(passphrase, salt, p.Time, p.MemoryKiB, p.Threads, uint32(keyLength)), nil
+	case Scrypt:
+		return scrypt.Key(passphrase, salt, 1<<p.LogN, int(p.R), int(p.P), keyLength)
+	case PBKDF2SHA256:
+		return pbkdf2.Key(passphrase, salt, int(p.Iterations), keyLength, sha256.New), nil
+	}
+	return nil, fmt.Errorf("unknown KDF %d", p.KDF)
+}
@@ -0,0 +1,160 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package pbe
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// minimumParams keeps the tests fast
+var minimumParams = []Params{
+	{KDF: Argon2id, Time: MinArgon2idTime, MemoryKiB: MinArgon2idMemoryKiB, Threads: 1},
+	{KDF: Scrypt, LogN: MinScryptLogN, R: MinScryptR, P: 1},
+	{KDF: PBKDF2SHA256, Iterations: MinPBKDF2Iterations},
+}
+
+func TestDeriveKey(t *testing.T) {
+	testData := []struct {
+		params   Params
+		password string
+		salt     string
+		expected string
+	}{
+		// RFC 7914, section 12
+		{Params{KDF: Scrypt, LogN: 10, R: 8, P: 16}, "password", "NaCl",
+			"fdbabe1c9d3472007856e7190d01e9fe7c6ad7cbc8237830e77376634b3731622eaf30d92e22a3886ff109279d9830dac727afb94a83ee6d8360cbdfa2cc0640"},
+		// RFC 7914, section 11
+		{Params{KDF: PBKDF2SHA256, Iterations: 1}, "passwd", "salt",
+			"55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"},
+	}
+
+	for _, data := range testData {
+		key, err := data.params.deriveKey([]byte(data.password), []byte(data.salt), len(data.expected)/2)
+		if err != nil || hex.EncodeToString(key) != data.expected {
+			t.Errorf("Expected %s but got %x (%v)", data.expected, key, err)
+		}
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	for _, params := range minimumParams {
+		encrypted, err := Encrypt([]byte("correct horse"), []byte("operator export"), params)
+		if err != nil {
+			t.Fatalf("Did not expect an error for KDF %d but got %q", params.KDF, err)
+		}
+
+		plainBytes, err := Decrypt([]byte("correct horse"), encrypted)
+		if err != nil || string(plainBytes) != "operator export" {
+			t.Errorf("Expected operator export but got %s (%v)", plainBytes, err)
+		}
+		if _, err := Decrypt([]byte("wrong horse"), encrypted); err == nil {
+			t.Error("should be an error if the passphrase is wrong")
+		}
+
+		readParams, err := ReadParams(encrypted)
+		if err != nil || readParams != params {
+			t.Errorf("Expected %+v but got %+v (%v)", params, readParams, err)
+		}
+
+		// Modifying the costs in the header is detected
+		tampered := append([]byte(nil), encrypted...)
+		tampered[len(tampered)-gcmOverhead-len("operator export")-saltLength-2]--
+		if _, err := Decrypt([]byte("correct horse"), tampered); err == nil {
+			t.Error("should be an error if the header is modified")
+		}
+	}
+}
+
+func TestMinimumCosts(t *testing.T) {
+	weakParams := []Params{
+		{KDF: Argon2id, Time: 1, MemoryKiB: 64 * 1024, Threads: 4},
+		{KDF: Argon2id, Time: 3, MemoryKiB: 1024, Threads: 4},
+		{KDF: Scrypt, LogN: 14, R: 8, P: 1},
+		{KDF: PBKDF2SHA256, Iterations: 1000},
+		{KDF: 42},
+	}
+	for _, params := range weakParams {
+		if _, err := Encrypt([]byte("correct horse"), []byte("operator export"), params); err == nil {
+			t.Errorf("should be an error for %+v", params)
+		}
+	}
+
+	for _, kdf := range []KDF{Argon2id, Scrypt, PBKDF2SHA256} {
+		if err := DefaultParams(kdf).Validate(); err != nil {
+			t.Errorf("Did not expect an error for the default parameters of KDF %d but got %q", kdf, err)
+		}
+	}
+
+	// A header asking for an unbounded cost is refused before deriving
+	encrypted, _ := Encrypt([]byte("correct horse"), []byte("operator export"), minimumParams[2])
+	encrypted[6] = 0xff
+	if _, err := Decrypt([]byte("correct horse"), encrypted); err == nil {
+		t.Error("should be an error if the header costs are too high")
+	}
+}
+
+func TestMaximumCosts(t *testing.T) {
+	// Headers asking for more than 1 GiB or too many passes, they would exhaust the memory or hang
+	// if the KDF ran
+	oversizedParams := []Params{
+		{KDF: Scrypt, LogN: 24, R: 8, P: 1},
+		{KDF: Scrypt, LogN: 20, R: 16, P: 1},
+		{KDF: Scrypt, LogN: 15, R: 1 << 20, P: 1},
+		{KDF: Scrypt, LogN: 15, R: 8, P: 1 << 20},
+		{KDF: Argon2id, Time: 3, MemoryKiB: 4 * 1024 * 1024, Threads: 4},
+		{KDF: Argon2id, Time: 64, MemoryKiB: 64 * 1024, Threads: 4},
+	}
+	for _, params := range oversizedParams {
+		encrypted := marshalHeader(header{params, make([]byte, saltLength)})
+		encrypted = append(encrypted, make([]byte, gcmOverhead)...)
+		if _, err := Decrypt([]byte("correct horse"), encrypted); err == nil || !strings.Contains(err.Error(), "too high") {
+			t.Errorf("should be an error before deriving for %+v but got %v", params, err)
+		}
+	}
+
+	// 1 GiB is still accepted
+	if err := (Params{KDF: Scrypt, LogN: 19, R: 15, P: 1}).Validate(); err != nil {
+		t.Errorf("Did not expect an error but got %q", err)
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	encrypted, _ := Encrypt([]byte("correct horse"), []byte("operator export"), minimumParams[2])
+
+	target := minimumParams[2]
+	if upgrade, _ := NeedsUpgrade(encrypted, target); upgrade {
+		t.Error("Did not expect an upgrade for the same parameters")
+	}
+
+	target = minimumParams[0]
+	if upgrade, _ := NeedsUpgrade(encrypted, target); !upgrade {
+		t.Error("Expected an upgrade to another KDF")
+	}
+
+	upgraded, err := Reencrypt([]byte("correct horse"), encrypted, target)
+	if err != nil {
+		t.Fatalf("Did not expect an error but got %q", err)
+	}
+	if upgrade, _ := NeedsUpgrade(upgraded, target); upgrade {
+		t.Error("Did not expect an upgrade after re-encryption")
+	}
+	plainBytes, err := Decrypt([]byte("correct horse"), upgraded)
+	if err != nil || string(plainBytes) != "operator export" {
+		t.Errorf("Expected operator export but got %s (%v)", plainBytes, err)
+	}
+
+	if _, err := NeedsUpgrade([]byte("not encrypted"), target); err != ErrInvalidFormat {
+		t.Errorf("Expected ErrInvalidFormat but got %v", err)
+	}
+}
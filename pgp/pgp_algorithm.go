@@ -0,0 +1,84 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package pgp
+
+import (
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+	"github.com/exohood/exohood-crypto-algorithms/algorithm"
+)
+
+var (
+	_ algorithm.Encrypter = (*ArmoredKeyPair)(nil)
+	_ algorithm.Decrypter = (*ArmoredKeyPair)(nil)
+	_ algorithm.Signer    = (*ArmoredKeyPair)(nil)
+	_ algorithm.Verifier  = (*ArmoredKeyPair)(nil)
+)
+
+// EncryptMessage encrypts a message with the public key and output the armored PGP message bytes
+func (pgp *ArmoredKeyPair) EncryptMessage(plainBytes []byte) ([]byte, error) {
+	armored, err := pgp.Encrypt(plainBytes)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(armored), nil
+}
+
+// DecryptMessage decrypts the armored PGP message bytes with the private key and its passphrase
+func (pgp *ArmoredKeyPair) DecryptMessage(cipherBytes []byte) ([]byte, error) {
+	return pgp.Decrypt(string(cipherBytes), pgp.Passphrase)
+}
+
+// Sign produces a binary detached signature of the message with the private key and its passphrase
+func (pgp *ArmoredKeyPair) Sign(message []byte) ([]byte, error) {
+	privateKeyObj, err := crypto.NewKeyFromArmored(pgp.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	locked, err := privateKeyObj.IsLocked()
+	if err != nil {
+		return nil, err
+	}
+	if locked {
+		privateKeyObj, err = privateKeyObj.Unlock(pgp.Passphrase)
+		if err != nil {
+			return nil, err
+		}
+		defer privateKeyObj.ClearPrivateParams()
+	}
+
+	keyRing, err := crypto.NewKeyRing(privateKeyObj)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := keyRing.SignDetached(crypto.NewPlainMessage(message))
+	if err != nil {
+		return nil, err
+	}
+	return signature.GetBinary(), nil
+}
+
+// Verify checks the binary detached signature of the message with the public key
+func (pgp *ArmoredKeyPair) Verify(message []byte, signature []byte) error {
+	publicKeyObj, err := crypto.NewKeyFromArmored(pgp.PublicKey)
+	if err != nil {
+		return err
+	}
+
+	keyRing, err := crypto.NewKeyRing(publicKeyObj)
+	if err != nil {
+		return err
+	}
+	return keyRing.VerifyDetached(crypto.NewPlainMessage(message), crypto.NewPGPSignature(signature), crypto.GetUnixTime())
+}
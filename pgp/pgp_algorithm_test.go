@@ -0,0 +1,76 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package pgp
+
+import (
+	"testing"
+
+	"github.com/ProtonMail/gopenpgp/v2/crypto"
+	"github.com/ProtonMail/gopenpgp/v2/helper"
+)
+
+func generateKeyPair(t *testing.T, passphrase []byte) ArmoredKeyPair {
+	private, err := helper.GenerateKey("test", "test@exohood.com", passphrase, "x25519", 0)
+	if err != nil {
+		t.Fatal("Failed to generate key", err)
+	}
+	privateKeyObj, err := crypto.NewKeyFromArmored(private)
+	if err != nil {
+		t.Fatal("Failed to read key", err)
+	}
+	public, err := privateKeyObj.GetArmoredPublicKey()
+	if err != nil {
+		t.Fatal("Failed to export public key", err)
+	}
+
+	return ArmoredKeyPair{
+		PrivateKey: private,
+		PublicKey:  public,
+		Passphrase: passphrase,
+	}
+}
+
+func TestEncryptDecryptMessage(t *testing.T) {
+	pgp := generateKeyPair(t, []byte("passphrase"))
+
+	enc, err := pgp.EncryptMessage([]byte("Secret text"))
+	if err != nil {
+		t.Fatal("Failed to encrypt text", err)
+	}
+	dec, err := pgp.DecryptMessage(enc)
+	if err != nil {
+		t.Fatal("Failed to decrypt text", err)
+	}
+	if string(dec) != "Secret text" {
+		t.Fatal("Decrypted text does not match")
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	pgp := generateKeyPair(t, []byte("passphrase"))
+
+	signature, err := pgp.Sign([]byte("Settlement file"))
+	if err != nil {
+		t.Fatal("Failed to sign text", err)
+	}
+	if err := pgp.Verify([]byte("Settlement file"), signature); err != nil {
+		t.Fatal("Failed to verify signature", err)
+	}
+	if err := pgp.Verify([]byte("Tampered file"), signature); err == nil {
+		t.Fatal("Expected the signature of another text to be invalid")
+	}
+
+	pgp.Passphrase = []byte("wrong")
+	if _, err := pgp.Sign([]byte("Settlement file")); err == nil {
+		t.Fatal("Expected an error with the wrong passphrase")
+	}
+}
@@ -19,6 +19,8 @@ import (
 type ArmoredKeyPair struct {
 	PrivateKey string
 	PublicKey  string
+	// Passphrase unlocks the private key when used through the algorithm interfaces
+	Passphrase []byte
 }
 
 // EvalHash generates a SHA256 hash as string for the public key
@@ -0,0 +1,82 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package rsa
+
+import (
+	"crypto/rsa"
+	"errors"
+
+	"github.com/exohood/exohood-crypto-algorithms/algorithm"
+)
+
+var (
+	_ algorithm.Encrypter = (*KeyPair)(nil)
+	_ algorithm.Decrypter = (*KeyPair)(nil)
+	_ algorithm.Signer    = (*KeyPair)(nil)
+	_ algorithm.Verifier  = (*KeyPair)(nil)
+)
+
+// KeyPair binds the package helpers to a key pair so it can be used through the algorithm
+// interfaces, the public key is taken from the private key when it is not set
+type KeyPair struct {
+	PrivateKey *rsa.PrivateKey
+	PublicKey  *rsa.PublicKey
+	// OAEP configures EncryptMessage and DecryptMessage, the zero value is RSA-OAEP with SHA-256
+	OAEP OAEPOptions
+	// Signature selects the scheme and the hash of Sign and Verify, the zero value is PSS with SHA-256
+	Signature SignatureOptions
+}
+
+// EncryptMessage encrypts a message with the public key using RSA-OAEP
+func (k *KeyPair) EncryptMessage(plainBytes []byte) ([]byte, error) {
+	publicKey := k.publicKey()
+	if publicKey == nil {
+		return nil, errors.New("no public key")
+	}
+	return EncryptOAEP(publicKey, plainBytes, k.OAEP)
+}
+
+// DecryptMessage decrypts a message with the private key using RSA-OAEP
+func (k *KeyPair) DecryptMessage(cipherBytes []byte) ([]byte, error) {
+	if k.PrivateKey == nil {
+		return nil, errors.New("no private key")
+	}
+	return DecryptOAEP(k.PrivateKey, cipherBytes, k.OAEP)
+}
+
+// Sign signs the message with the private key, see Sign
+func (k *KeyPair) Sign(message []byte) ([]byte, error) {
+	if k.PrivateKey == nil {
+		return nil, errors.New("no private key")
+	}
+	return Sign(k.PrivateKey, message, k.Signature)
+}
+
+// Verify checks the signature of the message with the public key, see Verify
+func (k *KeyPair) Verify(message []byte, signature []byte) error {
+	publicKey := k.publicKey()
+	if publicKey == nil {
+		return errors.New("no public key")
+	}
+	return Verify(publicKey, message, signature, k.Signature)
+}
+
+func (k *KeyPair) publicKey() *rsa.PublicKey {
+	if k.PublicKey != nil {
+		return k.PublicKey
+	}
+	if k.PrivateKey != nil {
+		return &k.PrivateKey.PublicKey
+	}
+	return nil
+}
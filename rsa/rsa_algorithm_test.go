@@ -0,0 +1,65 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package rsa
+
+import (
+	"context"
+	"testing"
+)
+
+func TestKeyPairEncryptDecrypt(t *testing.T) {
+	priv, err := GenerateKeyPair(context.Background(), KeyOptions{Bits: 2048})
+	if err != nil {
+		t.Fatal("Failed to generate key pair")
+	}
+
+	sender := KeyPair{PublicKey: &priv.PublicKey}
+	receiver := KeyPair{PrivateKey: priv}
+
+	enc, err := sender.EncryptMessage([]byte("Secret text"))
+	if err != nil {
+		t.Fatal("Failed to encrypt text")
+	}
+	dec, err := receiver.DecryptMessage(enc)
+	if err != nil {
+		t.Fatal("Failed to decrypt text")
+	}
+	if string(dec) != "Secret text" {
+		t.Fatal("Decrypted text does not match")
+	}
+
+	if _, err := sender.DecryptMessage(enc); err == nil {
+		t.Fatal("Expected an error without private key")
+	}
+}
+
+func TestKeyPairSignVerify(t *testing.T) {
+	priv, _ := LoadPrivateKey("testdata/private.pem")
+
+	signer := KeyPair{PrivateKey: priv, Signature: SignatureOptions{Scheme: PKCS1v15}}
+	verifier := KeyPair{PublicKey: &priv.PublicKey, Signature: SignatureOptions{Scheme: PKCS1v15}}
+
+	signature, err := signer.Sign([]byte("Signed text"))
+	if err != nil {
+		t.Fatal("Failed to sign text")
+	}
+	if err := verifier.Verify([]byte("Signed text"), signature); err != nil {
+		t.Fatal("Expected the signature to verify", err)
+	}
+	if err := verifier.Verify([]byte("Other text"), signature); err == nil {
+		t.Fatal("Expected an error for another text")
+	}
+
+	if _, err := verifier.Sign([]byte("Signed text")); err == nil {
+		t.Fatal("Expected an error without private key")
+	}
+}
@@ -0,0 +1,57 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package rsa
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+
+	"github.com/exohood/exohood-crypto-algorithms/cert"
+)
+
+// VerifiedPublicKey verifies a partner PEM or DER certificate, optionally followed by its
+// intermediates, against the trust store and returns its RSA public key. The certificate must
+// allow key encipherment on top of the options.
+func VerifiedPublicKey(store *cert.TrustStore, certificate []byte, opts cert.VerifyOptions) (*rsa.PublicKey, error) {
+	opts.KeyUsage |= x509.KeyUsageKeyEncipherment
+	publicKey, err := store.VerifyAndExtractPublicKey(certificate, opts)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := publicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("certificate key is not an RSA key but %T", publicKey)
+	}
+	return rsaKey, nil
+}
+
+// EncryptOAEPToCertificate encrypts a message with RSA-OAEP to the key of a certificate verified
+// with the verify options, see VerifiedPublicKey
+func EncryptOAEPToCertificate(store *cert.TrustStore, certificate []byte, plainBytes []byte, verifyOpts cert.VerifyOptions, opts OAEPOptions) ([]byte, error) {
+	publicKey, err := VerifiedPublicKey(store, certificate, verifyOpts)
+	if err != nil {
+		return nil, err
+	}
+	return EncryptOAEP(publicKey, plainBytes, opts)
+}
+
+// EncryptHybridToCertificate encrypts a payload of any size to the key of a certificate verified
+// with the verify options, see VerifiedPublicKey and EncryptHybrid
+func EncryptHybridToCertificate(store *cert.TrustStore, certificate []byte, plainBytes []byte, verifyOpts cert.VerifyOptions, scheme HybridScheme) ([]byte, error) {
+	publicKey, err := VerifiedPublicKey(store, certificate, verifyOpts)
+	if err != nil {
+		return nil, err
+	}
+	return EncryptHybrid(publicKey, plainBytes, scheme)
+}
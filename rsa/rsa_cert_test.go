@@ -0,0 +1,89 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package rsa
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/exohood/exohood-crypto-algorithms/cert"
+)
+
+func TestEncryptToCertificate(t *testing.T) {
+	priv, _ := LoadPrivateKey("testdata/private.pem")
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ca, _ := cert.NewCA(caKey, pkix.Name{CommonName: "Partner Root"}, 2*time.Hour)
+	store, _ := cert.NewTrustStore(ca.Certificate)
+
+	// RSA certificates allow key encipherment by default
+	certificate, _ := ca.Issue(&priv.PublicKey, cert.Request{Subject: pkix.Name{CommonName: "partner"}}, time.Hour)
+	certificatePEM := cert.MarshalCertificatePEM(certificate)
+
+	encrypted, err := EncryptOAEPToCertificate(store, certificatePEM, []byte("secret"), cert.VerifyOptions{}, OAEPOptions{})
+	if err != nil {
+		t.Fatal("Did not expect an error", err)
+	}
+	if decrypted, err := Decrypt(priv, encrypted); err != nil || string(decrypted) != "secret" {
+		t.Fatal("Expected the private key to decrypt", err)
+	}
+
+	payload := bytes.Repeat([]byte("config bundle "), 1000)
+	encrypted, err = EncryptHybridToCertificate(store, certificatePEM, payload, cert.VerifyOptions{}, RSAKEM)
+	if err != nil {
+		t.Fatal("Did not expect an error", err)
+	}
+	if decrypted, err := DecryptHybrid(priv, encrypted); err != nil || !bytes.Equal(decrypted, payload) {
+		t.Fatal("Expected the private key to decrypt the hybrid payload", err)
+	}
+
+	// The verify options are applied
+	requireCRL := cert.VerifyOptions{RequireCRL: true}
+	if _, err := EncryptHybridToCertificate(store, certificatePEM, payload, requireCRL, OAEPWrap); err == nil {
+		t.Fatal("Expected an error without a CRL of the issuer")
+	}
+
+	crl, _ := ca.CreateCRL(1, time.Hour, certificate.SerialNumber)
+	store.AddCRL(crl)
+	if _, err := EncryptOAEPToCertificate(store, certificatePEM, []byte("secret"), requireCRL, OAEPOptions{}); !errors.Is(err, cert.ErrRevoked) {
+		t.Fatal("Expected cert.ErrRevoked but got", err)
+	}
+}
+
+func TestVerifiedPublicKeyErrors(t *testing.T) {
+	priv, _ := LoadPrivateKey("testdata/private.pem")
+	caKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ca, _ := cert.NewCA(caKey, pkix.Name{CommonName: "Partner Root"}, 2*time.Hour)
+	store, _ := cert.NewTrustStore(ca.Certificate)
+
+	signing, _ := ca.Issue(&priv.PublicKey, cert.Request{KeyUsage: x509.KeyUsageDigitalSignature}, time.Hour)
+	if _, err := VerifiedPublicKey(store, signing.Raw, cert.VerifyOptions{}); !errors.Is(err, cert.ErrKeyUsage) {
+		t.Fatal("Expected cert.ErrKeyUsage for a signing certificate but got", err)
+	}
+
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ecCertificate, _ := ca.Issue(ecKey.Public(), cert.Request{KeyUsage: x509.KeyUsageKeyEncipherment}, time.Hour)
+	if _, err := VerifiedPublicKey(store, ecCertificate.Raw, cert.VerifyOptions{}); err == nil {
+		t.Fatal("Expected an error for an EC certificate")
+	}
+
+	selfSigned, _ := cert.CreateSelfSigned(priv, cert.Request{}, time.Hour)
+	if _, err := EncryptHybridToCertificate(store, selfSigned.Raw, []byte("secret"), cert.VerifyOptions{}, OAEPWrap); err == nil {
+		t.Fatal("Expected an error for an untrusted certificate")
+	}
+}
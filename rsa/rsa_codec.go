@@ -0,0 +1,238 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package rsa
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Format is the ASN.1 structure of an encoded key
+type Format int
+
+const (
+	// PKCS1 is the RSA specific RSAPrivateKey or RSAPublicKey structure of RFC 8017
+	PKCS1 Format = iota + 1
+	// PKCS8 is the algorithm agnostic PrivateKeyInfo structure of RFC 5208, for private keys
+	PKCS8
+	// PKIX is the algorithm agnostic SubjectPublicKeyInfo structure of RFC 5280, for public keys
+	PKIX
+)
+
+// PEM block types of the supported formats
+const (
+	pemTypePKCS1Private   = "RSA PRIVATE KEY"
+	pemTypePKCS8Private   = "PRIVATE KEY"
+	pemTypePKCS8Encrypted = "ENCRYPTED PRIVATE KEY"
+	pemTypePKCS1Public    = "RSA PUBLIC KEY"
+	pemTypePKIXPublic     = "PUBLIC KEY"
+)
+
+const (
+	privateKeyFileMode = 0600
+	publicKeyFileMode  = 0644
+)
+
+// ErrEncryptedKey is returned when loading an encrypted private key without its passphrase, see
+// ParseEncryptedPrivateKey
+var ErrEncryptedKey = errors.New("private key is encrypted")
+
+// MarshalPrivateKeyDER encodes the private key as PKCS1 or PKCS8 DER
+func MarshalPrivateKeyDER(key *rsa.PrivateKey, format Format) ([]byte, error) {
+	switch format {
+	case PKCS1:
+		return x509.MarshalPKCS1PrivateKey(key), nil
+	case PKCS8:
+		return x509.MarshalPKCS8PrivateKey(key)
+	}
+	return nil, errors.New("private keys can only be encoded as PKCS1 or PKCS8")
+}
+
+// MarshalPrivateKeyPEM encodes the private key as a PKCS1 (RSA PRIVATE KEY) or PKCS8 (PRIVATE KEY)
+// PEM block
+func MarshalPrivateKeyPEM(key *rsa.PrivateKey, format Format) ([]byte, error) {
+	der, err := MarshalPrivateKeyDER(key, format)
+	if err != nil {
+		return nil, err
+	}
+
+	blockType := pemTypePKCS1Private
+	if format == PKCS8 {
+		blockType = pemTypePKCS8Private
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), nil
+}
+
+// MarshalPublicKeyDER encodes the public key as PKCS1 or PKIX DER
+func MarshalPublicKeyDER(key *rsa.PublicKey, format Format) ([]byte, error) {
+	switch format {
+	case PKCS1:
+		return x509.MarshalPKCS1PublicKey(key), nil
+	case PKIX:
+		return x509.MarshalPKIXPublicKey(key)
+	}
+	return nil, errors.New("public keys can only be encoded as PKCS1 or PKIX")
+}
+
+// MarshalPublicKeyPEM encodes the public key as a PKCS1 (RSA PUBLIC KEY) or PKIX (PUBLIC KEY) PEM
+// block
+func MarshalPublicKeyPEM(key *rsa.PublicKey, format Format) ([]byte, error) {
+	der, err := MarshalPublicKeyDER(key, format)
+	if err != nil {
+		return nil, err
+	}
+
+	blockType := pemTypePKCS1Public
+	if format == PKIX {
+		blockType = pemTypePKIXPublic
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), nil
+}
+
+// ParsePrivateKey decodes a PEM or DER private key, detecting whether it is PKCS1 or PKCS8
+func ParsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	der, blockType, err := decodePEM(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch blockType {
+	case pemTypePKCS1Private:
+		return x509.ParsePKCS1PrivateKey(der)
+	case pemTypePKCS8Private:
+		return parsePKCS8PrivateKey(der)
+	case pemTypePKCS8Encrypted:
+		return nil, ErrEncryptedKey
+	case "":
+		if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+			return key, nil
+		}
+		return parsePKCS8PrivateKey(der)
+	}
+	return nil, fmt.Errorf("unexpected PEM block %q for a private key", blockType)
+}
+
+// ParsePublicKey decodes a PEM or DER public key, detecting whether it is PKCS1 or PKIX. A private
+// key is also accepted, its public key is returned.
+func ParsePublicKey(data []byte) (*rsa.PublicKey, error) {
+	der, blockType, err := decodePEM(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch blockType {
+	case pemTypePKCS1Public:
+		return x509.ParsePKCS1PublicKey(der)
+	case pemTypePKIXPublic:
+		return parsePKIXPublicKey(der)
+	case pemTypePKCS1Private, pemTypePKCS8Private, pemTypePKCS8Encrypted:
+		key, err := ParsePrivateKey(data)
+		if err != nil {
+			return nil, err
+		}
+		return &key.PublicKey, nil
+	case "":
+		if key, err := x509.ParsePKCS1PublicKey(der); err == nil {
+			return key, nil
+		}
+		if key, err := parsePKIXPublicKey(der); err == nil {
+			return key, nil
+		}
+		key, err := ParsePrivateKey(der)
+		if err != nil {
+			return nil, errors.New("input is not a PKCS1, PKIX or private RSA key")
+		}
+		return &key.PublicKey, nil
+	}
+	return nil, fmt.Errorf("unexpected PEM block %q for a public key", blockType)
+}
+
+// LoadPrivateKey reads a PEM or DER private key file, see ParsePrivateKey
+func LoadPrivateKey(path string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePrivateKey(data)
+}
+
+// LoadPublicKey reads a PEM or DER public key file, see ParsePublicKey
+func LoadPublicKey(path string) (*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePublicKey(data)
+}
+
+// StorePrivateKey writes the private key as a PEM file only readable by its owner
+func StorePrivateKey(path string, key *rsa.PrivateKey, format Format) error {
+	data, err := MarshalPrivateKeyPEM(key, format)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, privateKeyFileMode)
+}
+
+// StorePublicKey writes the public key as a PEM file
+func StorePublicKey(path string, key *rsa.PublicKey, format Format) error {
+	data, err := MarshalPublicKeyPEM(key, format)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, publicKeyFileMode)
+}
+
+// decodePEM returns the bytes and the type of the first PEM block, or the input itself with an
+// empty type if it is not PEM encoded
+func decodePEM(data []byte) ([]byte, string, error) {
+	trimmed := bytes.TrimSpace(data)
+	if !bytes.HasPrefix(trimmed, []byte("-----BEGIN ")) {
+		return data, "", nil
+	}
+
+	block, _ := pem.Decode(trimmed)
+	if block == nil {
+		return nil, "", errors.New("invalid PEM encoding")
+	}
+	return block.Bytes, block.Type, nil
+}
+
+func parsePKCS8PrivateKey(der []byte) (*rsa.PrivateKey, error) {
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("key is not an RSA key but %T", key)
+	}
+	return rsaKey, nil
+}
+
+func parsePKIXPublicKey(der []byte) (*rsa.PublicKey, error) {
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("key is not an RSA key but %T", key)
+	}
+	return rsaKey, nil
+}
@@ -0,0 +1,136 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package rsa
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadTestdata(t *testing.T) {
+	priv, err := LoadPrivateKey("testdata/private.pem")
+	if err != nil {
+		t.Fatal("Failed to load private key", err)
+	}
+	pub, err := LoadPublicKey("testdata/public.pem")
+	if err != nil {
+		t.Fatal("Failed to load public key", err)
+	}
+	if !priv.PublicKey.Equal(pub) {
+		t.Fatal("Expected the testdata public key to match the private key")
+	}
+
+	fromPrivate, err := LoadPublicKey("testdata/private.pem")
+	if err != nil || !fromPrivate.Equal(pub) {
+		t.Fatal("Expected the public key to be loaded from the private key file", err)
+	}
+}
+
+func TestEncodeDecodeFormats(t *testing.T) {
+	priv, _ := LoadPrivateKey("testdata/private.pem")
+
+	for _, format := range []Format{PKCS1, PKCS8} {
+		encoded, err := MarshalPrivateKeyPEM(priv, format)
+		if err != nil {
+			t.Fatalf("Did not expect an error for format %d but got %q", format, err)
+		}
+		decoded, err := ParsePrivateKey(encoded)
+		if err != nil || !priv.Equal(decoded) {
+			t.Errorf("Expected the PEM private key of format %d to round trip (%v)", format, err)
+		}
+
+		der, _ := MarshalPrivateKeyDER(priv, format)
+		decoded, err = ParsePrivateKey(der)
+		if err != nil || !priv.Equal(decoded) {
+			t.Errorf("Expected the DER private key of format %d to round trip (%v)", format, err)
+		}
+	}
+
+	for _, format := range []Format{PKCS1, PKIX} {
+		encoded, err := MarshalPublicKeyPEM(&priv.PublicKey, format)
+		if err != nil {
+			t.Fatalf("Did not expect an error for format %d but got %q", format, err)
+		}
+		decoded, err := ParsePublicKey(encoded)
+		if err != nil || !priv.PublicKey.Equal(decoded) {
+			t.Errorf("Expected the PEM public key of format %d to round trip (%v)", format, err)
+		}
+
+		der, _ := MarshalPublicKeyDER(&priv.PublicKey, format)
+		decoded, err = ParsePublicKey(der)
+		if err != nil || !priv.PublicKey.Equal(decoded) {
+			t.Errorf("Expected the DER public key of format %d to round trip (%v)", format, err)
+		}
+	}
+
+	if _, err := MarshalPrivateKeyPEM(priv, PKIX); err == nil {
+		t.Error("should be an error if a private key is encoded as PKIX")
+	}
+	if _, err := MarshalPublicKeyPEM(&priv.PublicKey, PKCS8); err == nil {
+		t.Error("should be an error if a public key is encoded as PKCS8")
+	}
+}
+
+func TestStoreLoad(t *testing.T) {
+	priv, _ := LoadPrivateKey("testdata/private.pem")
+	dir := t.TempDir()
+
+	privatePath := filepath.Join(dir, "private.pem")
+	if err := StorePrivateKey(privatePath, priv, PKCS8); err != nil {
+		t.Fatal("Failed to store private key", err)
+	}
+	if info, _ := os.Stat(privatePath); info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the private key file mode to be 0600 but got %v", info.Mode().Perm())
+	}
+	loaded, err := LoadPrivateKey(privatePath)
+	if err != nil || !priv.Equal(loaded) {
+		t.Error("Expected the stored private key to be loaded", err)
+	}
+
+	publicPath := filepath.Join(dir, "public.pem")
+	if err := StorePublicKey(publicPath, &priv.PublicKey, PKCS1); err != nil {
+		t.Fatal("Failed to store public key", err)
+	}
+	loadedPublic, err := LoadPublicKey(publicPath)
+	if err != nil || !priv.PublicKey.Equal(loadedPublic) {
+		t.Error("Expected the stored public key to be loaded", err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ecPrivate, _ := x509.MarshalPKCS8PrivateKey(ecKey)
+	ecPublic, _ := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+
+	if _, err := ParsePrivateKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: ecPrivate})); err == nil {
+		t.Error("should be an error if the PKCS8 key is not an RSA key")
+	}
+	if _, err := ParsePublicKey(ecPublic); err == nil {
+		t.Error("should be an error if the PKIX key is not an RSA key")
+	}
+	if _, err := ParsePrivateKey(pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: ecPrivate})); !errors.Is(err, ErrEncryptedKey) {
+		t.Errorf("Expected ErrEncryptedKey but got %v", err)
+	}
+	if _, err := ParsePrivateKey(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ecPrivate})); err == nil {
+		t.Error("should be an error for an unexpected PEM block")
+	}
+	if _, err := ParsePublicKey([]byte("-----BEGIN PUBLIC KEY-----\nnot base64")); err == nil {
+		t.Error("should be an error for an invalid PEM encoding")
+	}
+}
@@ -0,0 +1,226 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package rsa
+
+import (
+	"crypto/rsa"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+
+	"github.com/exohood/exohood-crypto-algorithms/aes"
+	"github.com/exohood/exohood-crypto-algorithms/des"
+)
+
+// WrapPadding is the RSA padding an HSM used to wrap a symmetric key
+type WrapPadding int
+
+const (
+	// WrapOAEP is RSAES-OAEP with the digests and label of WrapScheme.OAEP
+	WrapOAEP WrapPadding = iota + 1
+	// WrapPKCS1v15 is RSAES-PKCS1-v1_5, decrypted with implicit rejection
+	WrapPKCS1v15
+	// WrapRaw is RSA without padding, the key is laid out following WrapScheme.RawFormat
+	WrapRaw
+)
+
+// RawFormat is the layout of the key in a raw RSA block
+type RawFormat int
+
+const (
+	// RawZeroPadding expects the key in the last bytes of the block and zeros before it
+	RawZeroPadding RawFormat = iota + 1
+	// RawTrailingKey takes the key from the last bytes of the block without checking the bytes
+	// before it, for HSMs padding with random bytes
+	RawTrailingKey
+)
+
+// KeyAlgorithm is the algorithm of an extracted key
+type KeyAlgorithm int
+
+const (
+	// KeyDES is a single DES key of 8 bytes
+	KeyDES KeyAlgorithm = iota + 1
+	// KeyTripleDES is a double or triple length 3DES key of 16 or 24 bytes
+	KeyTripleDES
+	// KeyAES is an AES key of 16, 24 or 32 bytes
+	KeyAES
+)
+
+// WrapScheme describes how the key was wrapped under the RSA public key
+type WrapScheme struct {
+	Padding WrapPadding
+	// OAEP configures WrapOAEP, the zero value is SHA-256 with an empty label
+	OAEP OAEPOptions
+	// RawFormat configures WrapRaw
+	RawFormat RawFormat
+	// KeySize is the size of the wrapped key in bytes, it is required by WrapPKCS1v15 and WrapRaw
+	// and checked against the unwrapped key with WrapOAEP when set
+	KeySize int
+}
+
+// UnwrapStage is the step of a key extraction which failed
+type UnwrapStage int
+
+const (
+	// StageScheme is the validation of the wrap scheme, the key algorithm and the check value
+	StageScheme UnwrapStage = iota + 1
+	// StageDecrypt is the RSA decryption and the removal of its padding
+	StageDecrypt
+	// StageKey is the construction of the cipher from the unwrapped bytes
+	StageKey
+	// StageCheckValue is the verification of the key check value
+	StageCheckValue
+)
+
+func (s UnwrapStage) String() string {
+	switch s {
+	case StageScheme:
+		return "scheme validation"
+	case StageDecrypt:
+		return "decryption"
+	case StageKey:
+		return "key construction"
+	case StageCheckValue:
+		return "check value verification"
+	}
+	return fmt.Sprintf("stage %d", int(s))
+}
+
+// ErrCheckValueMismatch is the cause of an UnwrapError at StageCheckValue
+var ErrCheckValueMismatch = errors.New("key check value does not match")
+
+// UnwrapError explains which step of ExtractKey failed
+type UnwrapError struct {
+	Stage UnwrapStage
+	Err   error
+}
+
+func (e *UnwrapError) Error() string {
+	return fmt.Sprintf("key extraction failed at %s: %v", e.Stage, e.Err)
+}
+
+func (e *UnwrapError) Unwrap() error {
+	return e.Err
+}
+
+// ExtractedKey is a verified key, DES is set for KeyDES and KeyTripleDES and AES for KeyAES
+type ExtractedKey struct {
+	Algorithm KeyAlgorithm
+	DES       des.Cipher
+	AES       aes.Cipher
+}
+
+// ExtractKey unwraps a DES, 3DES or AES key exported by an HSM under the RSA public key and
+// verifies it against the expected key check value, i.e. the DES/3DES KCV or the AES CMAC KCV.
+// Failures are returned as *UnwrapError. With WrapPKCS1v15 and RawZeroPadding an invalid padding
+// is not reported, it fails the check value instead.
+//
+// WrapRaw runs the RSA private key operation with math/big, blinded but not constant time, and
+// should only be used for HSMs which cannot wrap with a padding.
+func ExtractKey(p *rsa.PrivateKey, wrapped []byte, scheme WrapScheme, keyAlgorithm KeyAlgorithm, checkValue string) (ExtractedKey, error) {
+	if checkValue == "" {
+		return ExtractedKey{}, &UnwrapError{StageScheme, errors.New("the expected key check value is required")}
+	}
+	if keyAlgorithm < KeyDES || keyAlgorithm > KeyAES {
+		return ExtractedKey{}, &UnwrapError{StageScheme, fmt.Errorf("unknown key algorithm %d", keyAlgorithm)}
+	}
+	if scheme.KeySize != 0 && !validKeySize(keyAlgorithm, scheme.KeySize) {
+		return ExtractedKey{}, &UnwrapError{StageScheme, fmt.Errorf("key size %d does not match the key algorithm", scheme.KeySize)}
+	}
+
+	keyBytes, paddingValid, err := unwrapKey(p, wrapped, scheme)
+	if err != nil {
+		return ExtractedKey{}, err
+	}
+	if scheme.KeySize != 0 && len(keyBytes) != scheme.KeySize {
+		return ExtractedKey{}, &UnwrapError{StageKey, fmt.Errorf("unwrapped key is %d bytes, expected %d", len(keyBytes), scheme.KeySize)}
+	}
+
+	key := ExtractedKey{Algorithm: keyAlgorithm}
+	var verified bool
+	switch keyAlgorithm {
+	case KeyDES, KeyTripleDES:
+		if keyAlgorithm == KeyDES {
+			key.DES, err = des.CreateFromDESKeyBytes(keyBytes)
+		} else {
+			key.DES, err = des.CreateFromTripleDESKeyBytes(keyBytes)
+		}
+		if err == nil {
+			verified = key.DES.VerifyCheckValue(checkValue)
+		}
+	case KeyAES:
+		if key.AES, err = aes.New(keyBytes); err == nil {
+			verified = key.AES.VerifyCheckValue(checkValue)
+		}
+	}
+	if err != nil {
+		return ExtractedKey{}, &UnwrapError{StageKey, err}
+	}
+	if !verified || !paddingValid {
+		return ExtractedKey{}, &UnwrapError{StageCheckValue, ErrCheckValueMismatch}
+	}
+	return key, nil
+}
+
+// unwrapKey removes the RSA encryption and its padding. A raw block which is not zero padded is
+// only reported by the returned flag, so the caller folds it into the check value verification.
+func unwrapKey(p *rsa.PrivateKey, wrapped []byte, scheme WrapScheme) ([]byte, bool, error) {
+	switch scheme.Padding {
+	case WrapOAEP:
+		keyBytes, err := DecryptOAEP(p, wrapped, scheme.OAEP)
+		if err != nil {
+			return nil, false, &UnwrapError{StageDecrypt, err}
+		}
+		return keyBytes, true, nil
+
+	case WrapPKCS1v15:
+		if scheme.KeySize == 0 {
+			return nil, false, &UnwrapError{StageScheme, errors.New("PKCS#1 v1.5 unwrapping requires the key size")}
+		}
+		keyBytes, err := DecryptPKCS1v15(p, wrapped, scheme.KeySize)
+		if err != nil {
+			return nil, false, &UnwrapError{StageDecrypt, err}
+		}
+		return keyBytes, true, nil
+
+	case WrapRaw:
+		if scheme.KeySize == 0 {
+			return nil, false, &UnwrapError{StageScheme, errors.New("raw RSA unwrapping requires the key size")}
+		}
+		if scheme.RawFormat != RawZeroPadding && scheme.RawFormat != RawTrailingKey {
+			return nil, false, &UnwrapError{StageScheme, fmt.Errorf("unknown raw format %d", scheme.RawFormat)}
+		}
+		block, err := decryptRaw(p, wrapped)
+		if err != nil {
+			return nil, false, &UnwrapError{StageDecrypt, err}
+		}
+		padding := block[:len(block)-scheme.KeySize]
+		paddingValid := scheme.RawFormat == RawTrailingKey ||
+			subtle.ConstantTimeCompare(padding, make([]byte, len(padding))) == 1
+		return block[len(block)-scheme.KeySize:], paddingValid, nil
+	}
+	return nil, false, &UnwrapError{StageScheme, fmt.Errorf("unknown wrap padding %d", scheme.Padding)}
+}
+
+func validKeySize(keyAlgorithm KeyAlgorithm, size int) bool {
+	switch keyAlgorithm {
+	case KeyDES:
+		return size == 8
+	case KeyTripleDES:
+		return size == 16 || size == 24
+	case KeyAES:
+		return size == 16 || size == 24 || size == 32
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package rsa
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func TestExtractKey(t *testing.T) {
+	priv, _ := LoadPrivateKey("testdata/private.pem")
+	desKey, _ := hex.DecodeString("0123456789ABCDEF")
+	tripleDESKey, _ := hex.DecodeString("F94AC55104B0E5532D0A61D2D2C6C655F94AC55104B0E553")
+	aesKey, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+
+	rawZero := func(keyBytes []byte) []byte {
+		block := make([]byte, priv.Size())
+		copy(block[len(block)-len(keyBytes):], keyBytes)
+		return encryptRaw(&priv.PublicKey, block)
+	}
+	rawRandom := func(keyBytes []byte) []byte {
+		block := make([]byte, priv.Size())
+		_, _ = rand.Read(block[1:])
+		copy(block[len(block)-len(keyBytes):], keyBytes)
+		return encryptRaw(&priv.PublicKey, block)
+	}
+	oaep := func(opts OAEPOptions) func([]byte) []byte {
+		return func(keyBytes []byte) []byte {
+			wrapped, _ := EncryptOAEP(&priv.PublicKey, keyBytes, opts)
+			return wrapped
+		}
+	}
+	pkcs1v15 := func(keyBytes []byte) []byte {
+		wrapped, _ := rsa.EncryptPKCS1v15(rand.Reader, &priv.PublicKey, keyBytes)
+		return wrapped
+	}
+
+	for _, tc := range []struct {
+		name         string
+		keyBytes     []byte
+		keyAlgorithm KeyAlgorithm
+		checkValue   string
+		scheme       WrapScheme
+		wrap         func([]byte) []byte
+	}{
+		{"DES OAEP", desKey, KeyDES, "D5D44F", WrapScheme{Padding: WrapOAEP}, oaep(OAEPOptions{})},
+		{"3DES OAEP-SHA1", tripleDESKey, KeyTripleDES, "6FAAD3", WrapScheme{Padding: WrapOAEP, OAEP: OAEPOptions{Hash: crypto.SHA1}}, oaep(OAEPOptions{Hash: crypto.SHA1})},
+		{"AES PKCS1v15", aesKey, KeyAES, "7AD386", WrapScheme{Padding: WrapPKCS1v15, KeySize: 16}, pkcs1v15},
+		{"3DES raw zero", tripleDESKey, KeyTripleDES, "6FAAD3", WrapScheme{Padding: WrapRaw, RawFormat: RawZeroPadding, KeySize: 24}, rawZero},
+		{"AES raw trailing", aesKey, KeyAES, "7ad386c376", WrapScheme{Padding: WrapRaw, RawFormat: RawTrailingKey, KeySize: 16}, rawRandom},
+	} {
+		wrapped := tc.wrap(tc.keyBytes)
+		key, err := ExtractKey(priv, wrapped, tc.scheme, tc.keyAlgorithm, tc.checkValue)
+		if err != nil {
+			t.Errorf("%s: did not expect an error but got %q", tc.name, err)
+			continue
+		}
+		if key.Algorithm != tc.keyAlgorithm {
+			t.Errorf("%s: expected the algorithm %d but got %d", tc.name, tc.keyAlgorithm, key.Algorithm)
+		}
+		keyBytes := key.DES.KeyBytes
+		if tc.keyAlgorithm == KeyAES {
+			keyBytes = key.AES.KeyBytes
+		}
+		if !bytes.Equal(keyBytes, tc.keyBytes) {
+			t.Errorf("%s: expected the key bytes %x but got %x", tc.name, tc.keyBytes, keyBytes)
+		}
+
+		var unwrapErr *UnwrapError
+		_, err = ExtractKey(priv, wrapped, tc.scheme, tc.keyAlgorithm, "000000")
+		if !errors.As(err, &unwrapErr) || unwrapErr.Stage != StageCheckValue || !errors.Is(err, ErrCheckValueMismatch) {
+			t.Errorf("%s: expected a check value mismatch but got %v", tc.name, err)
+		}
+	}
+}
+
+func TestExtractKeyErrors(t *testing.T) {
+	priv, _ := LoadPrivateKey("testdata/private.pem")
+	aesKey, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+	wrapped, _ := EncryptOAEP(&priv.PublicKey, aesKey, OAEPOptions{})
+
+	stage := func(err error) UnwrapStage {
+		var unwrapErr *UnwrapError
+		if errors.As(err, &unwrapErr) {
+			return unwrapErr.Stage
+		}
+		return 0
+	}
+
+	_, err := ExtractKey(priv, wrapped, WrapScheme{Padding: WrapOAEP, OAEP: OAEPOptions{Hash: crypto.SHA1}}, KeyAES, "7AD386")
+	if stage(err) != StageDecrypt {
+		t.Error("Expected a decryption failure for another OAEP digest but got", err)
+	}
+	_, err = ExtractKey(priv, wrapped, WrapScheme{Padding: WrapOAEP}, KeyDES, "7AD386")
+	if stage(err) != StageKey {
+		t.Error("Expected a key failure for an AES key extracted as DES but got", err)
+	}
+	_, err = ExtractKey(priv, wrapped, WrapScheme{Padding: WrapOAEP, KeySize: 32}, KeyAES, "7AD386")
+	if stage(err) != StageKey {
+		t.Error("Expected a key failure for another key size but got", err)
+	}
+
+	// An invalid PKCS#1 v1.5 padding is only detected by the check value
+	_, err = ExtractKey(priv, wrapped, WrapScheme{Padding: WrapPKCS1v15, KeySize: 16}, KeyAES, "7AD386")
+	if stage(err) != StageCheckValue {
+		t.Error("Expected a check value failure for an invalid padding but got", err)
+	}
+
+	// So is a raw block which is not zero padded
+	_, err = ExtractKey(priv, wrapped, WrapScheme{Padding: WrapRaw, RawFormat: RawZeroPadding, KeySize: 16}, KeyAES, "7AD386")
+	if stage(err) != StageCheckValue || !errors.Is(err, ErrCheckValueMismatch) {
+		t.Error("Expected a check value failure for a block which is not zero padded but got", err)
+	}
+
+	for _, scheme := range []WrapScheme{
+		{Padding: WrapPKCS1v15},
+		{Padding: WrapRaw, KeySize: 16},
+		{Padding: WrapRaw, RawFormat: RawZeroPadding, KeySize: 8},
+		{},
+	} {
+		if _, err := ExtractKey(priv, wrapped, scheme, KeyAES, "7AD386"); stage(err) != StageScheme {
+			t.Errorf("Expected a scheme failure for %+v but got %v", scheme, err)
+		}
+	}
+	if _, err := ExtractKey(priv, wrapped, WrapScheme{Padding: WrapOAEP}, KeyAES, ""); stage(err) != StageScheme {
+		t.Error("Expected a scheme failure without check value but got", err)
+	}
+	if _, err := ExtractKey(priv, wrapped, WrapScheme{Padding: WrapOAEP}, KeyAlgorithm(4), "7AD386"); stage(err) != StageScheme {
+		t.Error("Expected a scheme failure for an unknown key algorithm but got", err)
+	}
+}
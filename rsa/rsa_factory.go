@@ -19,13 +19,14 @@ import (
 	"encoding/base64"
 )
 
-// GenerateRSAKeyPair generates a RSA key pair for the provided bit size.
+// GenerateRSAKeyPair generates a 4096 bits RSA key pair, see GenerateKeyPair to choose the size.
 func GenerateRSAKeyPair() (*rsa.PrivateKey, error) {
 	reader := rand.Reader
 	return rsa.GenerateKey(reader, 4096)
 }
 
-// EvalHash generates a SHA256 hash as string for the provided pem block
+// EvalHash generates a SHA256 hash as string for the provided pem block, it is the legacy
+// fingerprint, see Fingerprint
 func EvalHash(p *rsa.PublicKey) string {
 	b := x509.MarshalPKCS1PublicKey(p)
 	h := sha256.Sum256(b)
@@ -40,7 +41,8 @@ func Encode(p *rsa.PublicKey) string {
 	return s
 }
 
-// Decode converts a base64 encoded pkcs1 string to a *rsa.PublicKey
+// Decode converts a base64 encoded pkcs1 string to a *rsa.PublicKey, the key is not verified, see
+// VerifiedPublicKey to only accept keys certified by a trust anchor
 func Decode(s string) (*rsa.PublicKey, error) {
 	der, err := base64.StdEncoding.DecodeString(s)
 	if err != nil {
@@ -49,12 +51,14 @@ func Decode(s string) (*rsa.PublicKey, error) {
 	return x509.ParsePKCS1PublicKey(der)
 }
 
-// Decrypt decrypts a message with the provided rsa.PrivateKey
+// Decrypt decrypts a message with the provided rsa.PrivateKey using RSA-OAEP with SHA-256, see
+// DecryptOAEP for other digests and labels
 func Decrypt(p *rsa.PrivateKey, cipher []byte) ([]byte, error) {
-	return rsa.DecryptOAEP(sha256.New(), rand.Reader, p, cipher, nil)
+	return DecryptOAEP(p, cipher, OAEPOptions{})
 }
 
-// Encrypt encrypts a message with the provided rsa.PublicKey
+// Encrypt encrypts a message with the provided rsa.PublicKey using RSA-OAEP with SHA-256, see
+// EncryptOAEP for other digests and labels
 func Encrypt(p *rsa.PublicKey, cipher []byte) ([]byte, error) {
-	return rsa.EncryptOAEP(sha256.New(), rand.Reader, p, cipher, nil)
+	return EncryptOAEP(p, cipher, OAEPOptions{})
 }
@@ -13,10 +13,6 @@ package rsa
 
 import (
 	"crypto/rsa"
-	"crypto/x509"
-	"encoding/pem"
-	"fmt"
-	"io/ioutil"
 	"testing"
 )
 
@@ -78,21 +74,5 @@ func TestEncryptDecrypt(t *testing.T) {
 }
 
 func readPublicKey(filename string) (*rsa.PublicKey, error) {
-	f, err := ioutil.ReadFile(filename)
-	if err != nil {
-		return nil, err
-	}
-
-	block, _ := pem.Decode(f)
-
-	parsedKey, err := x509.ParsePKIXPublicKey(block.Bytes)
-	if err != nil {
-		return nil, err
-	}
-
-	pubKey, ok := parsedKey.(*rsa.PublicKey)
-	if !ok {
-		return nil, fmt.Errorf("Parsed key not of the RSA")
-	}
-	return pubKey, nil
+	return LoadPublicKey(filename)
 }
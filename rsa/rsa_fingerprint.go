@@ -0,0 +1,46 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package rsa
+
+import (
+	"crypto/rsa"
+	"fmt"
+
+	"github.com/exohood/exohood-crypto-algorithms/jwk"
+)
+
+// FingerprintAlgorithm selects how Fingerprint identifies a public key
+type FingerprintAlgorithm int
+
+const (
+	// FingerprintJWK is the base64url RFC 7638 SHA-256 JWK thumbprint, which partners can compute
+	// from the published JWK Set
+	FingerprintJWK FingerprintAlgorithm = iota + 1
+	// FingerprintLegacy is the base64 SHA-256 of the PKCS#1 public key of EvalHash
+	FingerprintLegacy
+)
+
+// Fingerprint identifies the public key with the algorithm
+func Fingerprint(p *rsa.PublicKey, algorithm FingerprintAlgorithm) (string, error) {
+	switch algorithm {
+	case FingerprintJWK:
+		key, err := jwk.FromPublicKey(p)
+		if err != nil {
+			return "", err
+		}
+		return key.Thumbprint()
+	case FingerprintLegacy:
+		return EvalHash(p), nil
+	}
+	return "", fmt.Errorf("unknown fingerprint algorithm %d", algorithm)
+}
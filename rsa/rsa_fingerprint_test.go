@@ -0,0 +1,39 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package rsa
+
+import (
+	"crypto/rsa"
+	"testing"
+
+	"github.com/exohood/exohood-crypto-algorithms/jwk"
+)
+
+func TestFingerprint(t *testing.T) {
+	// RFC 7638 section 3.1
+	key, _ := jwk.Parse([]byte(`{"kty":"RSA","e":"AQAB","n":"0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw"}`))
+	pub, _ := key.PublicKey()
+
+	fingerprint, err := Fingerprint(pub.(*rsa.PublicKey), FingerprintJWK)
+	if err != nil || fingerprint != "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs" {
+		t.Fatalf("Expected the RFC 7638 thumbprint but got %s (%v)", fingerprint, err)
+	}
+
+	legacy, err := Fingerprint(pub.(*rsa.PublicKey), FingerprintLegacy)
+	if err != nil || legacy != EvalHash(pub.(*rsa.PublicKey)) {
+		t.Fatal("Expected the legacy fingerprint to be EvalHash", err)
+	}
+
+	if _, err := Fingerprint(pub.(*rsa.PublicKey), FingerprintAlgorithm(3)); err == nil {
+		t.Fatal("Expected an error for an unknown algorithm")
+	}
+}
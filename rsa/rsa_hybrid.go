@@ -0,0 +1,150 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package rsa
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/exohood/exohood-crypto-algorithms/aes"
+	"github.com/exohood/exohood-crypto-algorithms/kdf"
+)
+
+// HybridScheme is the way the AES key of a hybrid encryption is encapsulated
+type HybridScheme byte
+
+const (
+	// RSAKEM encrypts a random integer below the modulus and derives the AES key from it and the
+	// header with HKDF-SHA256. It follows the RSA-KEM construction but not its KDF, so it does not
+	// interoperate with ISO 18033-2 or RFC 5990.
+	RSAKEM HybridScheme = iota + 1
+	// OAEPWrap encrypts a random AES key with RSA-OAEP SHA-256
+	OAEPWrap
+)
+
+const (
+	hybridVersion   = 1
+	hybridKeyLength = 32
+	// hybridPrefixLength is the magic, the version and the scheme, followed by the length of the
+	// encapsulated key
+	hybridPrefixLength = 8
+)
+
+var (
+	hybridMagic = []byte("EXRH")
+
+	// ErrInvalidFormat is returned when the input is not a hybrid encrypted payload
+	ErrInvalidFormat = errors.New("invalid hybrid encryption format")
+)
+
+// EncryptHybrid encrypts a payload of any size to the public key: a fresh AES-256 key is
+// encapsulated with the scheme and the payload is encrypted with AES-256-GCM. The output is
+// "EXRH" | version | scheme | encapsulated key length (2 bytes) | encapsulated key | aes.Cipher
+// output, the header is bound to the AES key.
+func EncryptHybrid(p *rsa.PublicKey, plainBytes []byte, scheme HybridScheme) ([]byte, error) {
+	var encapsulated, keyBytes []byte
+	var err error
+	switch scheme {
+	case RSAKEM:
+		z, err := rand.Int(rand.Reader, p.N)
+		if err != nil {
+			return nil, err
+		}
+		secret := z.FillBytes(make([]byte, p.Size()))
+		encapsulated = encryptRaw(p, secret)
+		keyBytes, err = kdf.HKDF(secret, nil, marshalHybridHeader(scheme, encapsulated), hybridKeyLength)
+		if err != nil {
+			return nil, err
+		}
+	case OAEPWrap:
+		keyBytes = make([]byte, hybridKeyLength)
+		if _, err := io.ReadFull(rand.Reader, keyBytes); err != nil {
+			return nil, err
+		}
+		label := marshalHybridHeader(scheme, nil)[:hybridPrefixLength-2]
+		if encapsulated, err = EncryptOAEP(p, keyBytes, OAEPOptions{Label: label}); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown hybrid scheme %d", scheme)
+	}
+
+	cipher, err := aes.New(keyBytes)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.Seal(marshalHybridHeader(scheme, encapsulated), plainBytes)
+}
+
+// DecryptHybrid decrypts the output of EncryptHybrid with the private key, the scheme is read from
+// the header
+func DecryptHybrid(p *rsa.PrivateKey, encrypted []byte) ([]byte, error) {
+	if len(encrypted) < hybridPrefixLength || !bytes.Equal(encrypted[:len(hybridMagic)], hybridMagic) {
+		return nil, ErrInvalidFormat
+	}
+	if version := encrypted[len(hybridMagic)]; version != hybridVersion {
+		return nil, fmt.Errorf("unsupported hybrid encryption version %d", version)
+	}
+	scheme := HybridScheme(encrypted[len(hybridMagic)+1])
+	encapsulatedLength := int(binary.BigEndian.Uint16(encrypted[hybridPrefixLength-2:]))
+	if encapsulatedLength != p.Size() || len(encrypted) < hybridPrefixLength+encapsulatedLength {
+		return nil, ErrInvalidFormat
+	}
+	encapsulated := encrypted[hybridPrefixLength : hybridPrefixLength+encapsulatedLength]
+	sealed := encrypted[hybridPrefixLength+encapsulatedLength:]
+
+	var keyBytes []byte
+	var err error
+	switch scheme {
+	case RSAKEM:
+		secret, err := decryptRaw(p, encapsulated)
+		if err != nil {
+			return nil, err
+		}
+		keyBytes, err = kdf.HKDF(secret, nil, encrypted[:hybridPrefixLength+encapsulatedLength], hybridKeyLength)
+		if err != nil {
+			return nil, err
+		}
+	case OAEPWrap:
+		label := encrypted[:hybridPrefixLength-2]
+		if keyBytes, err = DecryptOAEP(p, encapsulated, OAEPOptions{Label: label}); err != nil {
+			return nil, err
+		}
+		if len(keyBytes) != hybridKeyLength {
+			return nil, ErrInvalidFormat
+		}
+	default:
+		return nil, fmt.Errorf("unknown hybrid scheme %d", scheme)
+	}
+
+	cipher, err := aes.New(keyBytes)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < cipher.NonceSize()+cipher.Overhead() {
+		return nil, ErrInvalidFormat
+	}
+	return cipher.Open(nil, sealed)
+}
+
+// marshalHybridHeader encodes the header up to and including the encapsulated key
+func marshalHybridHeader(scheme HybridScheme, encapsulated []byte) []byte {
+	out := append(append([]byte(nil), hybridMagic...), hybridVersion, byte(scheme))
+	out = binary.BigEndian.AppendUint16(out, uint16(len(encapsulated)))
+	return append(out, encapsulated...)
+}
@@ -0,0 +1,98 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package rsa
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"errors"
+	"testing"
+)
+
+func TestEncryptDecryptHybrid(t *testing.T) {
+	priv, _ := LoadPrivateKey("testdata/private.pem")
+	// Far beyond the 446 bytes RSA-OAEP SHA-256 can encrypt with a 4096 bits key
+	plainBytes := bytes.Repeat([]byte("config bundle "), 100000)
+
+	for _, scheme := range []HybridScheme{RSAKEM, OAEPWrap} {
+		encrypted, err := EncryptHybrid(&priv.PublicKey, plainBytes, scheme)
+		if err != nil {
+			t.Fatalf("Did not expect an error for scheme %d but got %q", scheme, err)
+		}
+		decrypted, err := DecryptHybrid(priv, encrypted)
+		if err != nil || !bytes.Equal(decrypted, plainBytes) {
+			t.Fatalf("Expected scheme %d to round trip (%v)", scheme, err)
+		}
+
+		empty, _ := EncryptHybrid(&priv.PublicKey, nil, scheme)
+		if decrypted, err := DecryptHybrid(priv, empty); err != nil || len(decrypted) != 0 {
+			t.Errorf("Expected an empty payload to round trip for scheme %d (%v)", scheme, err)
+		}
+
+		// Every part of the output is authenticated
+		for _, i := range []int{5, 6, 7, 100, len(encrypted) - 1} {
+			tampered := append([]byte(nil), encrypted...)
+			tampered[i] ^= 1
+			if _, err := DecryptHybrid(priv, tampered); err == nil {
+				t.Errorf("Expected an error for scheme %d with byte %d modified", scheme, i)
+			}
+		}
+
+		if _, err := DecryptHybrid(priv, encrypted[:len(encrypted)-20]); err == nil {
+			t.Errorf("Expected an error for a truncated output of scheme %d", scheme)
+		}
+	}
+}
+
+func TestDecryptHybridErrors(t *testing.T) {
+	priv, _ := LoadPrivateKey("testdata/private.pem")
+	other, _ := GenerateKeyPair(context.Background(), KeyOptions{Bits: 2048})
+	encrypted, _ := EncryptHybrid(&priv.PublicKey, []byte("secret"), RSAKEM)
+
+	if _, err := DecryptHybrid(other, encrypted); !errors.Is(err, ErrInvalidFormat) {
+		t.Fatal("Expected ErrInvalidFormat for a key of another size but got", err)
+	}
+	if _, err := DecryptHybrid(priv, []byte("EXPB")); !errors.Is(err, ErrInvalidFormat) {
+		t.Fatal("Expected ErrInvalidFormat but got", err)
+	}
+
+	version := append([]byte(nil), encrypted...)
+	version[4] = 2
+	if _, err := DecryptHybrid(priv, version); err == nil {
+		t.Fatal("Expected an error for an unknown version")
+	}
+
+	if _, err := EncryptHybrid(&priv.PublicKey, []byte("secret"), HybridScheme(3)); err == nil {
+		t.Fatal("Expected an error for an unknown scheme")
+	}
+}
+
+func TestRawRoundTrip(t *testing.T) {
+	priv, _ := LoadPrivateKey("testdata/private.pem")
+	em := bytes.Repeat([]byte{0x5a}, priv.Size())
+	em[0] = 0
+
+	decrypted, err := decryptRaw(priv, encryptRaw(&priv.PublicKey, em))
+	if err != nil || !bytes.Equal(decrypted, em) {
+		t.Fatal("Expected the raw RSA operations to round trip", err)
+	}
+	if _, err := decryptRaw(priv, priv.N.Bytes()); err == nil {
+		t.Fatal("Expected an error for the modulus")
+	}
+
+	// The exponent blinding needs the primes
+	withoutPrimes := &rsa.PrivateKey{PublicKey: priv.PublicKey, D: priv.D}
+	if _, err := decryptRaw(withoutPrimes, encryptRaw(&priv.PublicKey, em)); err == nil {
+		t.Fatal("Expected an error for a key without primes")
+	}
+}
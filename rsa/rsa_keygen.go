@@ -0,0 +1,178 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package rsa
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+const (
+	// DefaultBits is the key size used when KeyOptions.Bits is not set
+	DefaultBits = 4096
+	// DefaultMinBits is the floor used when KeyOptions.MinBits is not set
+	DefaultMinBits = 2048
+	// DefaultPublicExponent is the public exponent used when KeyOptions.PublicExponent is not set
+	DefaultPublicExponent = 65537
+
+	// absoluteMinBits is the lowest floor which can be configured
+	absoluteMinBits = 1024
+	// maxBits bounds the key size, larger keys take minutes to generate and are of no use
+	maxBits = 16384
+	// minPublicExponent is the FIPS 186-5 lower bound, the public exponent must be above 2^16
+	minPublicExponent = 1 << 16
+	// primeRounds is the number of Miller-Rabin rounds run on every candidate prime
+	primeRounds = 20
+)
+
+// KeyOptions configures GenerateKeyPair, the zero value generates a 4096 bits key with e = 65537
+type KeyOptions struct {
+	// Bits is the size of the modulus, e.g. 2048, 3072 or 4096
+	Bits int
+	// PublicExponent must be odd and above 2^16, some HSMs only accept 65537
+	PublicExponent int
+	// MinBits is the smallest accepted Bits, it cannot be set below 1024
+	MinBits int
+	// Random is the source of randomness, crypto/rand is used if not set
+	Random io.Reader
+}
+
+// GenerateKeyPair generates an RSA key pair following the options, it returns the context error as
+// soon as the context is done. The primes are drawn by this package rather than rsa.GenerateKey,
+// which supports neither other public exponents nor cancellation. The returned key is validated
+// and precomputed, ready for the PKCS#1 or PKCS#8 encoders.
+func GenerateKeyPair(ctx context.Context, opts KeyOptions) (*rsa.PrivateKey, error) {
+	bits, exponent, minBits, random := opts.Bits, opts.PublicExponent, opts.MinBits, opts.Random
+	if bits == 0 {
+		bits = DefaultBits
+	}
+	if exponent == 0 {
+		exponent = DefaultPublicExponent
+	}
+	if minBits == 0 {
+		minBits = DefaultMinBits
+	}
+	if random == nil {
+		random = rand.Reader
+	}
+
+	if minBits < absoluteMinBits {
+		return nil, fmt.Errorf("minimum key size cannot be below %d bits", absoluteMinBits)
+	}
+	if bits < minBits {
+		return nil, fmt.Errorf("key size must be at least %d bits", minBits)
+	}
+	if bits > maxBits {
+		return nil, fmt.Errorf("key size cannot be above %d bits", maxBits)
+	}
+	if exponent <= minPublicExponent || exponent%2 == 0 {
+		return nil, errors.New("public exponent must be odd and above 2^16")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return generateKey(ctx, random, bits, exponent)
+}
+
+// generateKey draws the two primes and derives the private exponent, stopping with the context
+// error as soon as the context is done
+func generateKey(ctx context.Context, random io.Reader, bits int, exponent int) (*rsa.PrivateKey, error) {
+	e := big.NewInt(int64(exponent))
+	one := big.NewInt(1)
+	// FIPS 186-4 B.3.1: |p - q| > 2^(nlen/2 - 100) and d > 2^(nlen/2)
+	minDistance := new(big.Int).Lsh(one, uint(bits/2-100))
+	minD := new(big.Int).Lsh(one, uint(bits/2))
+
+	for {
+		p, err := generatePrime(ctx, random, (bits+1)/2, e)
+		if err != nil {
+			return nil, err
+		}
+		q, err := generatePrime(ctx, random, bits/2, e)
+		if err != nil {
+			return nil, err
+		}
+
+		if new(big.Int).Sub(p, q).CmpAbs(minDistance) <= 0 {
+			continue
+		}
+
+		n := new(big.Int).Mul(p, q)
+		pMinus1 := new(big.Int).Sub(p, one)
+		qMinus1 := new(big.Int).Sub(q, one)
+		gcd := new(big.Int).GCD(nil, nil, pMinus1, qMinus1)
+		lambda := new(big.Int).Mul(pMinus1, qMinus1)
+		lambda.Div(lambda, gcd)
+
+		d := new(big.Int).ModInverse(e, lambda)
+		if d == nil || d.Cmp(minD) <= 0 {
+			continue
+		}
+
+		key := &rsa.PrivateKey{
+			PublicKey: rsa.PublicKey{N: n, E: exponent},
+			D:         d,
+			Primes:    []*big.Int{p, q},
+		}
+		if err := key.Validate(); err != nil {
+			return nil, err
+		}
+		key.Precompute()
+		return key, nil
+	}
+}
+
+// generatePrime draws random odd candidates of the bit size with the two top bits set, so the
+// product of two primes has exactly the sum of their sizes, until one is prime and coprime with
+// the exponent
+func generatePrime(ctx context.Context, random io.Reader, bits int, e *big.Int) (*big.Int, error) {
+	buf := make([]byte, (bits+7)/8)
+	candidate := new(big.Int)
+	one := big.NewInt(1)
+	gcd := new(big.Int)
+
+	// The top bits are set within the first byte, after the excess bits are cleared
+	excess := uint(len(buf)*8 - bits)
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if _, err := io.ReadFull(random, buf); err != nil {
+			return nil, err
+		}
+		buf[0] &= byte(0xff >> excess)
+		if excess <= 6 {
+			buf[0] |= byte(0xc0 >> excess)
+		} else {
+			buf[0] |= 0x01
+			buf[1] |= 0x80
+		}
+		buf[len(buf)-1] |= 1
+
+		candidate.SetBytes(buf)
+		if !candidate.ProbablyPrime(primeRounds) {
+			continue
+		}
+		if gcd.GCD(nil, nil, new(big.Int).Sub(candidate, one), e).Cmp(one) != 0 {
+			continue
+		}
+		return candidate, nil
+	}
+}
@@ -0,0 +1,98 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package rsa
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGenerateKeyPair(t *testing.T) {
+	testData := []KeyOptions{
+		{Bits: 2048},
+		{Bits: 1024, MinBits: 1024, PublicExponent: 65539},
+		{Bits: 1031, MinBits: 1024},
+		{Bits: 1031, MinBits: 1024, PublicExponent: 1<<16 + 3},
+	}
+
+	for _, opts := range testData {
+		key, err := GenerateKeyPair(context.Background(), opts)
+		if err != nil {
+			t.Fatalf("Did not expect an error for %+v but got %q", opts, err)
+		}
+
+		if key.N.BitLen() != opts.Bits {
+			t.Errorf("Expected a %d bits modulus but got %d bits", opts.Bits, key.N.BitLen())
+		}
+		expectedExponent := opts.PublicExponent
+		if expectedExponent == 0 {
+			expectedExponent = DefaultPublicExponent
+		}
+		if key.E != expectedExponent {
+			t.Errorf("Expected public exponent %d but got %d", expectedExponent, key.E)
+		}
+
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatalf("Did not expect a PKCS#8 error but got %q", err)
+		}
+		if _, err := x509.ParsePKCS8PrivateKey(der); err != nil {
+			t.Fatalf("Did not expect a PKCS#8 parsing error but got %q", err)
+		}
+
+		enc, _ := Encrypt(&key.PublicKey, []byte("Secret text"))
+		dec, err := Decrypt(key, enc)
+		if err != nil || string(dec) != "Secret text" {
+			t.Errorf("Expected Secret text but got %s (%v)", dec, err)
+		}
+	}
+}
+
+func TestGenerateKeyPair_Validation(t *testing.T) {
+	testData := []KeyOptions{
+		{Bits: 1024},
+		{Bits: 3072, MinBits: 4096},
+		{Bits: 512, MinBits: 512},
+		{Bits: 32768},
+		{Bits: 2048, PublicExponent: 65536},
+		{Bits: 2048, PublicExponent: 65535},
+		{Bits: 2048, PublicExponent: 3},
+		{Bits: 2048, PublicExponent: 1},
+	}
+
+	for _, opts := range testData {
+		if _, err := GenerateKeyPair(context.Background(), opts); err == nil {
+			t.Errorf("should be an error for %+v", opts)
+		}
+	}
+}
+
+func TestGenerateKeyPair_Cancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := GenerateKeyPair(ctx, KeyOptions{Bits: 2048}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled but got %v", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := GenerateKeyPair(ctx, KeyOptions{Bits: 16384}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded but got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the generation to stop shortly after the deadline but it took %v", elapsed)
+	}
+}
@@ -0,0 +1,153 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package rsa
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	_ "crypto/sha1"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/exohood/exohood-crypto-algorithms/kdf"
+)
+
+// rejectionLabel separates the synthetic keys of PKCS#1 v1.5 implicit rejection from other
+// derivations of the private key
+var rejectionLabel = []byte("exohood rsa pkcs1v15 implicit rejection")
+
+// OAEPOptions configures RSAES-OAEP, the zero value is SHA-256 for both the digest and MGF1 with an
+// empty label, as used by Encrypt and Decrypt
+type OAEPOptions struct {
+	// Hash is the label digest, either crypto.SHA1, crypto.SHA256, crypto.SHA384 or crypto.SHA512
+	Hash crypto.Hash
+	// MGFHash is the MGF1 digest, Hash is used when it is not set
+	MGFHash crypto.Hash
+	Label   []byte
+}
+
+// EncryptOAEP encrypts a message with the public key using RSA-OAEP with the options
+func EncryptOAEP(p *rsa.PublicKey, plainBytes []byte, opts OAEPOptions) ([]byte, error) {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	if opts.MGFHash == opts.Hash {
+		return rsa.EncryptOAEP(opts.Hash.New(), rand.Reader, p, plainBytes, opts.Label)
+	}
+	return encryptOAEP(rand.Reader, p, plainBytes, opts)
+}
+
+// DecryptOAEP decrypts a message with the private key using RSA-OAEP with the options
+func DecryptOAEP(p *rsa.PrivateKey, cipherBytes []byte, opts OAEPOptions) ([]byte, error) {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	return p.Decrypt(rand.Reader, cipherBytes, &rsa.OAEPOptions{Hash: opts.Hash, MGFHash: opts.MGFHash, Label: opts.Label})
+}
+
+// DecryptPKCS1v15 decrypts a session key of keySize bytes wrapped with RSAES-PKCS1-v1_5, for legacy
+// key transport only. To avoid Bleichenbacher oracles an invalid padding or a message of another
+// size is not reported: a synthetic key derived from the private key and the cipher bytes is
+// returned instead, so the caller only learns that the key is wrong from its own later checks,
+// e.g. a key check value or an authenticated decryption. Only invalid sizes return an error.
+func DecryptPKCS1v15(p *rsa.PrivateKey, cipherBytes []byte, keySize int) ([]byte, error) {
+	if keySize <= 0 || keySize > p.Size()-11 {
+		return nil, fmt.Errorf("session key size must be between 1 and %d bytes", p.Size()-11)
+	}
+	if len(cipherBytes) != p.Size() {
+		return nil, fmt.Errorf("cipher bytes must be %d bytes", p.Size())
+	}
+
+	kdk := sha256.Sum256(p.D.FillBytes(make([]byte, p.Size())))
+	key, err := kdf.CounterMode(kdf.HMACSHA256, kdk[:], rejectionLabel, cipherBytes, keySize)
+	if err != nil {
+		return nil, err
+	}
+	if err := rsa.DecryptPKCS1v15SessionKey(rand.Reader, p, cipherBytes, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (opts OAEPOptions) withDefaults() (OAEPOptions, error) {
+	if opts.Hash == 0 {
+		opts.Hash = crypto.SHA256
+	}
+	if opts.MGFHash == 0 {
+		opts.MGFHash = opts.Hash
+	}
+	for _, h := range []crypto.Hash{opts.Hash, opts.MGFHash} {
+		if h != crypto.SHA1 && h != crypto.SHA256 && h != crypto.SHA384 && h != crypto.SHA512 {
+			return opts, errors.New("OAEP hash must be either SHA-1, SHA-256, SHA-384 or SHA-512")
+		}
+	}
+	return opts, nil
+}
+
+// encryptOAEP implements RFC 8017 section 7.1.1 with distinct label and MGF1 digests, which the
+// standard library only supports for decryption
+func encryptOAEP(random io.Reader, p *rsa.PublicKey, plainBytes []byte, opts OAEPOptions) ([]byte, error) {
+	k := p.Size()
+	hLen := opts.Hash.Size()
+	if len(plainBytes) > k-2*hLen-2 {
+		return nil, rsa.ErrMessageTooLong
+	}
+
+	// EM = 0x00 || maskedSeed || maskedDB, DB = lHash || PS || 0x01 || M
+	em := make([]byte, k)
+	seed := em[1 : 1+hLen]
+	db := em[1+hLen:]
+
+	h := opts.Hash.New()
+	h.Write(opts.Label)
+	h.Sum(db[:0])
+	db[len(db)-len(plainBytes)-1] = 1
+	copy(db[len(db)-len(plainBytes):], plainBytes)
+
+	if _, err := io.ReadFull(random, seed); err != nil {
+		return nil, err
+	}
+	mgf := opts.MGFHash.New()
+	mgf1XOR(db, mgf, seed)
+	mgf1XOR(seed, mgf, db)
+
+	return encryptRaw(p, em), nil
+}
+
+// mgf1XOR XORs out with the MGF1 mask of the seed
+func mgf1XOR(out []byte, h hash.Hash, seed []byte) {
+	var counter [4]byte
+	var digest []byte
+	for done := 0; done < len(out); {
+		h.Reset()
+		h.Write(seed)
+		h.Write(counter[:])
+		digest = h.Sum(digest[:0])
+
+		for i := 0; i < len(digest) && done < len(out); i++ {
+			out[done] ^= digest[i]
+			done++
+		}
+		for i := 3; i >= 0; i-- {
+			if counter[i]++; counter[i] != 0 {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,125 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package rsa
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"os"
+	"testing"
+)
+
+// wrappedKey is the session key wrapped in the testdata files
+var wrappedKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestDecryptOpenSSLOAEP(t *testing.T) {
+	priv, _ := LoadPrivateKey("testdata/private.pem")
+
+	// Written by openssl pkeyutl -encrypt with rsa_oaep_md, rsa_mgf1_md and rsa_oaep_label
+	for _, tc := range []struct {
+		path string
+		opts OAEPOptions
+	}{
+		{"testdata/wrapped_oaep_sha1.bin", OAEPOptions{Hash: crypto.SHA1}},
+		{"testdata/wrapped_oaep_sha256_mgf1sha1.bin", OAEPOptions{Hash: crypto.SHA256, MGFHash: crypto.SHA1, Label: []byte("key wrap")}},
+	} {
+		cipherBytes, _ := os.ReadFile(tc.path)
+		plainBytes, err := DecryptOAEP(priv, cipherBytes, tc.opts)
+		if err != nil || !bytes.Equal(plainBytes, wrappedKey) {
+			t.Errorf("Expected %s to decrypt to the session key (%v)", tc.path, err)
+		}
+		if _, err := Decrypt(priv, cipherBytes); err == nil {
+			t.Errorf("Expected an error when decrypting %s with the default options", tc.path)
+		}
+	}
+}
+
+func TestEncryptDecryptOAEP(t *testing.T) {
+	priv, _ := LoadPrivateKey("testdata/private.pem")
+
+	for _, opts := range []OAEPOptions{
+		{},
+		{Hash: crypto.SHA1},
+		{Hash: crypto.SHA256, MGFHash: crypto.SHA1, Label: []byte("key wrap")},
+		{Hash: crypto.SHA512, MGFHash: crypto.SHA256},
+		{Hash: crypto.SHA384, Label: []byte("label")},
+	} {
+		cipherBytes, err := EncryptOAEP(&priv.PublicKey, wrappedKey, opts)
+		if err != nil {
+			t.Fatalf("Did not expect an error for %+v but got %q", opts, err)
+		}
+		plainBytes, err := DecryptOAEP(priv, cipherBytes, opts)
+		if err != nil || !bytes.Equal(plainBytes, wrappedKey) {
+			t.Errorf("Expected %+v to round trip (%v)", opts, err)
+		}
+
+		// The standard library is the reference for a distinct MGF1 digest
+		hash, mgfHash := opts.Hash, opts.MGFHash
+		if hash == 0 {
+			hash = crypto.SHA256
+		}
+		if mgfHash == 0 {
+			mgfHash = hash
+		}
+		plainBytes, err = priv.Decrypt(rand.Reader, cipherBytes, &rsa.OAEPOptions{Hash: hash, MGFHash: mgfHash, Label: opts.Label})
+		if err != nil || !bytes.Equal(plainBytes, wrappedKey) {
+			t.Errorf("Expected crypto/rsa to decrypt %+v (%v)", opts, err)
+		}
+
+		wrongLabel := opts
+		wrongLabel.Label = []byte("other")
+		if _, err := DecryptOAEP(priv, cipherBytes, wrongLabel); err == nil {
+			t.Errorf("Expected an error for another label than %+v", opts)
+		}
+	}
+
+	if _, err := EncryptOAEP(&priv.PublicKey, wrappedKey, OAEPOptions{Hash: crypto.MD5}); err == nil {
+		t.Fatal("Expected an error for MD5")
+	}
+	if _, err := EncryptOAEP(&priv.PublicKey, make([]byte, priv.Size()-2*32-1), OAEPOptions{MGFHash: crypto.SHA1}); err == nil {
+		t.Fatal("Expected an error for a message too long")
+	}
+}
+
+func TestDecryptPKCS1v15(t *testing.T) {
+	priv, _ := LoadPrivateKey("testdata/private.pem")
+
+	// Written by openssl pkeyutl -encrypt with rsa_padding_mode:pkcs1
+	cipherBytes, _ := os.ReadFile("testdata/wrapped_pkcs1v15.bin")
+	key, err := DecryptPKCS1v15(priv, cipherBytes, len(wrappedKey))
+	if err != nil || !bytes.Equal(key, wrappedKey) {
+		t.Fatal("Expected the session key", err)
+	}
+
+	// A wrong size is rejected implicitly with a synthetic key, which is stable for the same input
+	synthetic, err := DecryptPKCS1v15(priv, cipherBytes, 16)
+	if err != nil || len(synthetic) != 16 || bytes.Equal(synthetic, wrappedKey[:16]) {
+		t.Fatal("Expected a synthetic key for another size", err)
+	}
+	again, _ := DecryptPKCS1v15(priv, cipherBytes, 16)
+	if !bytes.Equal(synthetic, again) {
+		t.Fatal("Expected the synthetic key to be deterministic")
+	}
+
+	// So is an invalid padding
+	oaep, _ := os.ReadFile("testdata/wrapped_oaep_sha1.bin")
+	synthetic, err = DecryptPKCS1v15(priv, oaep, len(wrappedKey))
+	if err != nil || bytes.Equal(synthetic, wrappedKey) {
+		t.Fatal("Expected a synthetic key for an invalid padding", err)
+	}
+
+	if _, err := DecryptPKCS1v15(priv, cipherBytes[1:], len(wrappedKey)); err == nil {
+		t.Fatal("Expected an error for a truncated input")
+	}
+}
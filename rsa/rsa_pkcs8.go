@@ -0,0 +1,411 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package rsa
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"math/bits"
+	"os"
+
+	"github.com/exohood/exohood-crypto-algorithms/aes"
+	"github.com/exohood/exohood-crypto-algorithms/pbe"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// PKCS8Cipher is the encryption scheme of an encrypted PKCS#8 private key
+type PKCS8Cipher int
+
+const (
+	// AES256CBC is AES-256-CBC with PKCS#7 padding, the scheme OpenSSL reads and writes
+	AES256CBC PKCS8Cipher = iota + 1
+	// AES256GCM is AES-256-GCM as per RFC 5084, it is authenticated but the OpenSSL command line
+	// does not support AEAD ciphers in PKCS#8 files
+	AES256GCM
+)
+
+const (
+	pkcs8KeyLength  = 32
+	pkcs8SaltLength = 16
+	pkcs8GCMNonce   = 12
+	pkcs8GCMTagSize = 16
+	// The maximum costs bound the work an imported file can ask for
+	pkcs8MaxIterations   = 100000000
+	pkcs8MaxScryptMemory = 1 << 30
+	// opensslMaxScryptMemory is the scrypt memory limit of OpenSSL when it opens PKCS#8 files
+	opensslMaxScryptMemory = 32 << 20
+	// minScryptWork is the smallest N * p of the OWASP scrypt recommendations with r = 8, which
+	// range from N=2^17, p=1 to N=2^14, p=5
+	minScryptWork = 5 << 14
+)
+
+var (
+	oidPBES2          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidScrypt         = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11591, 4, 11}
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidAES256CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	oidAES256GCM      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 46}
+
+	errUnsupportedPKCS8 = errors.New("unsupported encrypted PKCS#8 algorithm")
+
+	// ErrIncorrectPassphrase is returned when an encrypted private key cannot be decrypted, either
+	// because the passphrase is wrong or because the file is corrupted
+	ErrIncorrectPassphrase = errors.New("incorrect passphrase or corrupted private key")
+)
+
+// PKCS8EncryptionOptions selects the KDF, its costs and the cipher of an encrypted PKCS#8 key.
+// The zero value uses PBKDF2-HMAC-SHA256 with the pbe default iterations and AES-256-CBC, which
+// opens with OpenSSL 1.1 and later.
+type PKCS8EncryptionOptions struct {
+	// Params must use the pbe.PBKDF2SHA256 KDF with at least the pbe minimum iterations, or the
+	// pbe.Scrypt KDF within the OpenSSL memory limit, see PKCS8ScryptParams
+	Params pbe.Params
+	Cipher PKCS8Cipher
+}
+
+// PKCS8ScryptParams returns the scrypt costs N=2^14, r=8 and p=5, which follow the OWASP
+// recommendations and stay within the 32MiB OpenSSL accepts when opening PKCS#8 files
+func PKCS8ScryptParams() pbe.Params {
+	return pbe.Params{KDF: pbe.Scrypt, LogN: 14, R: 8, P: 5}
+}
+
+// ASN.1 structures of RFC 5958, RFC 8018, RFC 7914 and RFC 5084
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+type scryptParams struct {
+	Salt                     []byte
+	CostParameter            int
+	BlockSize                int
+	ParallelizationParameter int
+	KeyLength                int `asn1:"optional"`
+}
+
+type gcmParams struct {
+	Nonce  []byte
+	ICVLen int `asn1:"default:12"`
+}
+
+// MarshalEncryptedPrivateKeyDER encrypts the private key under the passphrase as a PBES2
+// encrypted PKCS#8 structure
+func MarshalEncryptedPrivateKeyDER(key *rsa.PrivateKey, passphrase []byte, opts PKCS8EncryptionOptions) ([]byte, error) {
+	params, cipherType := opts.Params, opts.Cipher
+	if params.KDF == 0 {
+		params = pbe.DefaultParams(pbe.PBKDF2SHA256)
+	}
+	if cipherType == 0 {
+		cipherType = AES256CBC
+	}
+	if err := validatePKCS8Params(params); err != nil {
+		return nil, err
+	}
+
+	plainBytes, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, pkcs8SaltLength)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	kdf, keyBytes, err := deriveKeyPBES2(passphrase, salt, params)
+	if err != nil {
+		return nil, err
+	}
+
+	scheme, encryptedData, err := encryptPBES2(keyBytes, plainBytes, cipherType)
+	if err != nil {
+		return nil, err
+	}
+
+	parameters, err := asn1.Marshal(pbes2Params{kdf, scheme})
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(encryptedPrivateKeyInfo{
+		Algorithm:     pkix.AlgorithmIdentifier{Algorithm: oidPBES2, Parameters: asn1.RawValue{FullBytes: parameters}},
+		EncryptedData: encryptedData,
+	})
+}
+
+// MarshalEncryptedPrivateKeyPEM encrypts the private key as an ENCRYPTED PRIVATE KEY PEM block
+func MarshalEncryptedPrivateKeyPEM(key *rsa.PrivateKey, passphrase []byte, opts PKCS8EncryptionOptions) ([]byte, error) {
+	der, err := MarshalEncryptedPrivateKeyDER(key, passphrase, opts)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: pemTypePKCS8Encrypted, Bytes: der}), nil
+}
+
+// ParseEncryptedPrivateKey decrypts a PEM or DER encrypted PKCS#8 private key with the passphrase.
+// Costs below the pbe minimums are accepted so that keys exported by other tools can be imported.
+func ParseEncryptedPrivateKey(data []byte, passphrase []byte) (*rsa.PrivateKey, error) {
+	der, blockType, err := decodePEM(data)
+	if err != nil {
+		return nil, err
+	}
+	if blockType != "" && blockType != pemTypePKCS8Encrypted {
+		return nil, fmt.Errorf("unexpected PEM block %q for an encrypted private key", blockType)
+	}
+
+	var info encryptedPrivateKeyInfo
+	if rest, err := asn1.Unmarshal(der, &info); err != nil || len(rest) != 0 {
+		return nil, errors.New("invalid encrypted PKCS#8 structure")
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, errUnsupportedPKCS8
+	}
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, errors.New("invalid PBES2 parameters")
+	}
+
+	keyBytes, err := deriveKeyFromAlgorithm(passphrase, params.KeyDerivationFunc)
+	if err != nil {
+		return nil, err
+	}
+	plainBytes, err := decryptPBES2(keyBytes, params.EncryptionScheme, info.EncryptedData)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := parsePKCS8PrivateKey(plainBytes)
+	if err != nil {
+		return nil, ErrIncorrectPassphrase
+	}
+	return key, nil
+}
+
+// LoadEncryptedPrivateKey reads and decrypts an encrypted PKCS#8 private key file
+func LoadEncryptedPrivateKey(path string, passphrase []byte) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseEncryptedPrivateKey(data, passphrase)
+}
+
+// StoreEncryptedPrivateKey writes the private key as an encrypted PKCS#8 PEM file only readable by
+// its owner
+func StoreEncryptedPrivateKey(path string, key *rsa.PrivateKey, passphrase []byte, opts PKCS8EncryptionOptions) error {
+	data, err := MarshalEncryptedPrivateKeyPEM(key, passphrase, opts)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, privateKeyFileMode)
+}
+
+// ChangePassphrase decrypts the encrypted private key with the old passphrase and encrypts it
+// again as PEM with the new passphrase, a fresh salt and the options, which may raise the costs
+func ChangePassphrase(data []byte, oldPassphrase []byte, newPassphrase []byte, opts PKCS8EncryptionOptions) ([]byte, error) {
+	key, err := ParseEncryptedPrivateKey(data, oldPassphrase)
+	if err != nil {
+		return nil, err
+	}
+	return MarshalEncryptedPrivateKeyPEM(key, newPassphrase, opts)
+}
+
+// validatePKCS8Params checks the costs of an exported key, the memory of scrypt is bound so that
+// OpenSSL can open the file
+func validatePKCS8Params(params pbe.Params) error {
+	switch params.KDF {
+	case pbe.PBKDF2SHA256:
+		return params.Validate()
+	case pbe.Scrypt:
+		if params.LogN == 0 || params.LogN > 30 || params.R < pbe.MinScryptR || params.P == 0 ||
+			uint64(1)<<params.LogN*uint64(params.P) < minScryptWork {
+			return errors.New("scrypt costs must be at least N=2^14, r=8 and p=5, or an equivalent N*p")
+		}
+		if scryptMemory(1<<params.LogN, uint64(params.R), uint64(params.P)) > opensslMaxScryptMemory {
+			return errors.New("scrypt costs exceed the 32MiB OpenSSL can use to open the key")
+		}
+		return nil
+	}
+	return errors.New("encrypted PKCS#8 keys only support the PBKDF2 and scrypt KDFs")
+}
+
+// scryptMemory is the memory scrypt needs as computed by OpenSSL
+func scryptMemory(n, r, p uint64) uint64 {
+	return 128*r*(n+2) + 128*r*p
+}
+
+// deriveKeyPBES2 derives the encryption key and returns the KDF algorithm identifier
+func deriveKeyPBES2(passphrase []byte, salt []byte, params pbe.Params) (pkix.AlgorithmIdentifier, []byte, error) {
+	var oid asn1.ObjectIdentifier
+	var kdfParams interface{}
+	var keyBytes []byte
+	var err error
+
+	if params.KDF == pbe.Scrypt {
+		oid = oidScrypt
+		kdfParams = scryptParams{salt, 1 << params.LogN, int(params.R), int(params.P), 0}
+		keyBytes, err = scrypt.Key(passphrase, salt, 1<<params.LogN, int(params.R), int(params.P), pkcs8KeyLength)
+	} else {
+		oid = oidPBKDF2
+		prf := pkix.AlgorithmIdentifier{Algorithm: oidHMACWithSHA256, Parameters: asn1.NullRawValue}
+		kdfParams = pbkdf2Params{salt, int(params.Iterations), 0, prf}
+		keyBytes = pbkdf2.Key(passphrase, salt, int(params.Iterations), pkcs8KeyLength, sha256.New)
+	}
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, err
+	}
+
+	encoded, err := asn1.Marshal(kdfParams)
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, nil, err
+	}
+	return pkix.AlgorithmIdentifier{Algorithm: oid, Parameters: asn1.RawValue{FullBytes: encoded}}, keyBytes, nil
+}
+
+// deriveKeyFromAlgorithm derives the encryption key from a parsed KDF algorithm identifier,
+// refusing costs above the maximums
+func deriveKeyFromAlgorithm(passphrase []byte, kdf pkix.AlgorithmIdentifier) ([]byte, error) {
+	switch {
+	case kdf.Algorithm.Equal(oidPBKDF2):
+		var params pbkdf2Params
+		if _, err := asn1.Unmarshal(kdf.Parameters.FullBytes, &params); err != nil {
+			return nil, errors.New("invalid PBKDF2 parameters")
+		}
+		if params.IterationCount <= 0 || params.IterationCount > pkcs8MaxIterations {
+			return nil, errors.New("PBKDF2 iterations are out of range")
+		}
+		if params.KeyLength != 0 && params.KeyLength != pkcs8KeyLength {
+			return nil, errUnsupportedPKCS8
+		}
+
+		var h func() hash.Hash
+		switch {
+		case len(params.PRF.Algorithm) == 0 || params.PRF.Algorithm.Equal(oidHMACWithSHA1):
+			h = sha1.New
+		case params.PRF.Algorithm.Equal(oidHMACWithSHA256):
+			h = sha256.New
+		default:
+			return nil, errUnsupportedPKCS8
+		}
+		return pbkdf2.Key(passphrase, params.Salt, params.IterationCount, pkcs8KeyLength, h), nil
+
+	case kdf.Algorithm.Equal(oidScrypt):
+		var params scryptParams
+		if _, err := asn1.Unmarshal(kdf.Parameters.FullBytes, &params); err != nil {
+			return nil, errors.New("invalid scrypt parameters")
+		}
+		n, r, p := params.CostParameter, params.BlockSize, params.ParallelizationParameter
+		if n <= 1 || bits.OnesCount(uint(n)) != 1 || r <= 0 || p <= 0 || uint64(r)*uint64(p) >= 1<<30 ||
+			scryptMemory(uint64(n), uint64(r), uint64(p)) > pkcs8MaxScryptMemory {
+			return nil, errors.New("scrypt parameters are out of range")
+		}
+		if params.KeyLength != 0 && params.KeyLength != pkcs8KeyLength {
+			return nil, errUnsupportedPKCS8
+		}
+		return scrypt.Key(passphrase, params.Salt, n, r, p, pkcs8KeyLength)
+	}
+	return nil, errUnsupportedPKCS8
+}
+
+// encryptPBES2 encrypts the PKCS#8 structure and returns the encryption scheme identifier
+func encryptPBES2(keyBytes []byte, plainBytes []byte, cipherType PKCS8Cipher) (pkix.AlgorithmIdentifier, []byte, error) {
+	switch cipherType {
+	case AES256CBC:
+		cipher, err := aes.NewLegacy(keyBytes, aes.LegacyConfig{Mode: aes.CBC, Padding: aes.PKCS7Padding, AllowUnauthenticated: true})
+		if err != nil {
+			return pkix.AlgorithmIdentifier{}, nil, err
+		}
+		cipherBytes, iv, err := cipher.Encrypt(plainBytes, false)
+		if err != nil {
+			return pkix.AlgorithmIdentifier{}, nil, err
+		}
+		encodedIV, _ := asn1.Marshal(iv)
+		return pkix.AlgorithmIdentifier{Algorithm: oidAES256CBC, Parameters: asn1.RawValue{FullBytes: encodedIV}}, cipherBytes, nil
+
+	case AES256GCM:
+		cipher, err := aes.New(keyBytes)
+		if err != nil {
+			return pkix.AlgorithmIdentifier{}, nil, err
+		}
+		nonce := make([]byte, pkcs8GCMNonce)
+		cipherBytes, err := cipher.SealWithNonce(nil, nonce, plainBytes)
+		if err != nil {
+			return pkix.AlgorithmIdentifier{}, nil, err
+		}
+		encoded, _ := asn1.Marshal(gcmParams{nonce, pkcs8GCMTagSize})
+		return pkix.AlgorithmIdentifier{Algorithm: oidAES256GCM, Parameters: asn1.RawValue{FullBytes: encoded}}, cipherBytes, nil
+	}
+	return pkix.AlgorithmIdentifier{}, nil, errors.New("unknown PKCS#8 cipher")
+}
+
+// decryptPBES2 decrypts the PKCS#8 structure with the parsed encryption scheme
+func decryptPBES2(keyBytes []byte, scheme pkix.AlgorithmIdentifier, cipherBytes []byte) ([]byte, error) {
+	switch {
+	case scheme.Algorithm.Equal(oidAES256CBC):
+		var iv []byte
+		if _, err := asn1.Unmarshal(scheme.Parameters.FullBytes, &iv); err != nil || len(iv) != 16 {
+			return nil, errors.New("invalid AES-256-CBC parameters")
+		}
+		cipher, err := aes.NewLegacy(keyBytes, aes.LegacyConfig{Mode: aes.CBC, Padding: aes.PKCS7Padding, AllowUnauthenticated: true})
+		if err != nil {
+			return nil, err
+		}
+		plainBytes, err := cipher.Decrypt(cipherBytes, iv)
+		if err != nil {
+			return nil, ErrIncorrectPassphrase
+		}
+		return plainBytes, nil
+
+	case scheme.Algorithm.Equal(oidAES256GCM):
+		var params gcmParams
+		if _, err := asn1.Unmarshal(scheme.Parameters.FullBytes, &params); err != nil {
+			return nil, errors.New("invalid AES-256-GCM parameters")
+		}
+		if len(params.Nonce) != pkcs8GCMNonce || params.ICVLen != pkcs8GCMTagSize {
+			return nil, errUnsupportedPKCS8
+		}
+		cipher, err := aes.New(keyBytes)
+		if err != nil {
+			return nil, err
+		}
+		plainBytes, err := cipher.OpenWithNonce(nil, params.Nonce, cipherBytes)
+		if err != nil {
+			return nil, ErrIncorrectPassphrase
+		}
+		return plainBytes, nil
+	}
+	return nil, errUnsupportedPKCS8
+}
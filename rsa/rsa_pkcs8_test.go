@@ -0,0 +1,127 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package rsa
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/exohood/exohood-crypto-algorithms/pbe"
+)
+
+// minPBKDF2Params keeps the tests fast while passing the export checks
+var minPBKDF2Params = pbe.Params{KDF: pbe.PBKDF2SHA256, Iterations: pbe.MinPBKDF2Iterations}
+
+func TestParseOpenSSLEncryptedKeys(t *testing.T) {
+	priv, _ := LoadPrivateKey("testdata/private.pem")
+
+	// Written by openssl pkcs8 -topk8 -v2 aes-256-cbc, with -v2prf hmacWithSHA256 or -scrypt
+	for _, path := range []string{"testdata/private_pbkdf2.pem", "testdata/private_scrypt.pem"} {
+		decoded, err := LoadEncryptedPrivateKey(path, []byte("testdata"))
+		if err != nil || !priv.Equal(decoded) {
+			t.Errorf("Expected %s to decrypt to the testdata key (%v)", path, err)
+		}
+
+		if _, err := LoadEncryptedPrivateKey(path, []byte("wrong")); !errors.Is(err, ErrIncorrectPassphrase) {
+			t.Errorf("Expected ErrIncorrectPassphrase for %s but got %v", path, err)
+		}
+		if _, err := LoadPrivateKey(path); !errors.Is(err, ErrEncryptedKey) {
+			t.Errorf("Expected ErrEncryptedKey when loading %s without passphrase but got %v", path, err)
+		}
+	}
+}
+
+func TestEncryptedPrivateKeyRoundTrip(t *testing.T) {
+	priv, _ := LoadPrivateKey("testdata/private.pem")
+	passphrase := []byte("correct horse battery staple")
+
+	for _, params := range []pbe.Params{minPBKDF2Params, PKCS8ScryptParams()} {
+		for _, cipherType := range []PKCS8Cipher{AES256CBC, AES256GCM} {
+			opts := PKCS8EncryptionOptions{Params: params, Cipher: cipherType}
+			encoded, err := MarshalEncryptedPrivateKeyPEM(priv, passphrase, opts)
+			if err != nil {
+				t.Fatalf("Did not expect an error for KDF %d and cipher %d but got %q", params.KDF, cipherType, err)
+			}
+			decoded, err := ParseEncryptedPrivateKey(encoded, passphrase)
+			if err != nil || !priv.Equal(decoded) {
+				t.Errorf("Expected the PEM key of KDF %d and cipher %d to round trip (%v)", params.KDF, cipherType, err)
+			}
+
+			der, _ := MarshalEncryptedPrivateKeyDER(priv, passphrase, opts)
+			decoded, err = ParseEncryptedPrivateKey(der, passphrase)
+			if err != nil || !priv.Equal(decoded) {
+				t.Errorf("Expected the DER key of KDF %d and cipher %d to round trip (%v)", params.KDF, cipherType, err)
+			}
+
+			if _, err := ParseEncryptedPrivateKey(encoded, []byte("wrong")); !errors.Is(err, ErrIncorrectPassphrase) {
+				t.Errorf("Expected ErrIncorrectPassphrase for KDF %d and cipher %d but got %v", params.KDF, cipherType, err)
+			}
+		}
+	}
+}
+
+func TestEncryptedPrivateKeyWeakParams(t *testing.T) {
+	priv, _ := LoadPrivateKey("testdata/private.pem")
+
+	for _, params := range []pbe.Params{
+		{KDF: pbe.PBKDF2SHA256, Iterations: 2048},
+		{KDF: pbe.Scrypt, LogN: 14, R: 8, P: 1},
+		{KDF: pbe.Scrypt, LogN: 14, R: 4, P: 10},
+		// Above the OpenSSL memory limit
+		pbe.DefaultParams(pbe.Scrypt),
+		pbe.DefaultParams(pbe.Argon2id),
+	} {
+		if _, err := MarshalEncryptedPrivateKeyPEM(priv, []byte("secret"), PKCS8EncryptionOptions{Params: params}); err == nil {
+			t.Errorf("Expected an error for the parameters %+v", params)
+		}
+	}
+}
+
+func TestChangePassphrase(t *testing.T) {
+	priv, _ := LoadPrivateKey("testdata/private.pem")
+	original, _ := os.ReadFile("testdata/private_pbkdf2.pem")
+
+	changed, err := ChangePassphrase(original, []byte("testdata"), []byte("new passphrase"), PKCS8EncryptionOptions{Params: minPBKDF2Params})
+	if err != nil {
+		t.Fatal("Did not expect an error", err)
+	}
+	decoded, err := ParseEncryptedPrivateKey(changed, []byte("new passphrase"))
+	if err != nil || !priv.Equal(decoded) {
+		t.Fatal("Expected the key to decrypt with the new passphrase", err)
+	}
+	if _, err := ParseEncryptedPrivateKey(changed, []byte("testdata")); !errors.Is(err, ErrIncorrectPassphrase) {
+		t.Fatal("Expected the old passphrase to be rejected but got", err)
+	}
+
+	if _, err := ChangePassphrase(original, []byte("wrong"), []byte("new passphrase"), PKCS8EncryptionOptions{}); !errors.Is(err, ErrIncorrectPassphrase) {
+		t.Fatal("Expected ErrIncorrectPassphrase but got", err)
+	}
+}
+
+func TestStoreEncryptedPrivateKey(t *testing.T) {
+	priv, _ := LoadPrivateKey("testdata/private.pem")
+	path := filepath.Join(t.TempDir(), "private.pem")
+
+	if err := StoreEncryptedPrivateKey(path, priv, []byte("secret"), PKCS8EncryptionOptions{Params: minPBKDF2Params}); err != nil {
+		t.Fatal("Did not expect an error", err)
+	}
+	info, _ := os.Stat(path)
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("Expected the file mode 0600 but got %o", info.Mode().Perm())
+	}
+	decoded, err := LoadEncryptedPrivateKey(path, []byte("secret"))
+	if err != nil || !priv.Equal(decoded) {
+		t.Fatal("Expected the stored key to load", err)
+	}
+}
@@ -0,0 +1,78 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package rsa
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// encryptRaw is the RSA public key operation on an encoded message of the modulus size
+func encryptRaw(p *rsa.PublicKey, em []byte) []byte {
+	m := new(big.Int).SetBytes(em)
+	c := m.Exp(m, big.NewInt(int64(p.E)), p.N)
+	return c.FillBytes(make([]byte, p.Size()))
+}
+
+// exponentBlindingBits is the size of the random multiple of φ(n) added to the private exponent
+const exponentBlindingBits = 64
+
+// decryptRaw is the RSA private key operation without padding. math/big is not constant time, so
+// both the input and the private exponent are blinded with fresh random values: the timing
+// depends neither on the input nor on a fixed exponent, d + k·φ(n) changes at every call. The
+// result is checked with the public key so a faulty computation never leaks.
+func decryptRaw(p *rsa.PrivateKey, cipherBytes []byte) ([]byte, error) {
+	c := new(big.Int).SetBytes(cipherBytes)
+	if len(cipherBytes) != p.Size() || c.Cmp(p.N) >= 0 {
+		return nil, fmt.Errorf("cipher bytes must be a %d bytes integer below the modulus", p.Size())
+	}
+	if len(p.Primes) < 2 {
+		return nil, errors.New("the private key must hold its primes")
+	}
+
+	e := big.NewInt(int64(p.E))
+	var r, rInv *big.Int
+	for rInv == nil {
+		var err error
+		if r, err = rand.Int(rand.Reader, p.N); err != nil {
+			return nil, err
+		}
+		if r.Sign() > 0 {
+			rInv = new(big.Int).ModInverse(r, p.N)
+		}
+	}
+
+	phi := big.NewInt(1)
+	one := big.NewInt(1)
+	for _, prime := range p.Primes {
+		phi.Mul(phi, new(big.Int).Sub(prime, one))
+	}
+	k, err := rand.Int(rand.Reader, new(big.Int).Lsh(one, exponentBlindingBits))
+	if err != nil {
+		return nil, err
+	}
+	d := k.Mul(k, phi).Add(k, p.D)
+
+	blinded := new(big.Int).Exp(r, e, p.N)
+	blinded.Mul(blinded, c).Mod(blinded, p.N)
+	m := blinded.Exp(blinded, d, p.N)
+	m.Mul(m, rInv).Mod(m, p.N)
+
+	if new(big.Int).Exp(m, e, p.N).Cmp(c) != 0 {
+		return nil, errors.New("RSA private key operation failed")
+	}
+	return m.FillBytes(make([]byte, p.Size())), nil
+}
@@ -0,0 +1,174 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+package rsa
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// SignatureScheme is the RSA signature padding
+type SignatureScheme int
+
+const (
+	// PSS is RSASSA-PSS of RFC 8017 with MGF1 over the same hash, signatures use a salt as long as
+	// the hash
+	PSS SignatureScheme = iota + 1
+	// PKCS1v15 is RSASSA-PKCS1-v1_5 of RFC 8017, for peers which do not support PSS
+	PKCS1v15
+)
+
+const signatureFileMode = 0644
+
+// ErrInvalidSignature is returned when a signature does not match the message and the public key
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// SignatureOptions selects the scheme and the hash of a signature, the zero value is PSS with
+// SHA-256
+type SignatureOptions struct {
+	Scheme SignatureScheme
+	// Hash is either crypto.SHA256, crypto.SHA384 or crypto.SHA512
+	Hash crypto.Hash
+}
+
+// Sign signs the message with the private key
+func Sign(key *rsa.PrivateKey, message []byte, opts SignatureOptions) ([]byte, error) {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	h := opts.Hash.New()
+	h.Write(message)
+	return signDigest(key, h.Sum(nil), opts)
+}
+
+// Verify checks the signature of the message with the public key, it returns ErrInvalidSignature
+// when the signature does not match. PSS signatures are accepted with any salt length.
+func Verify(key *rsa.PublicKey, message []byte, signature []byte, opts SignatureOptions) error {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return err
+	}
+	h := opts.Hash.New()
+	h.Write(message)
+	return verifyDigest(key, h.Sum(nil), signature, opts)
+}
+
+// SignReader signs everything read from the reader, without holding it in memory
+func SignReader(key *rsa.PrivateKey, r io.Reader, opts SignatureOptions) ([]byte, error) {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return nil, err
+	}
+	digest, err := digestReader(r, opts.Hash)
+	if err != nil {
+		return nil, err
+	}
+	return signDigest(key, digest, opts)
+}
+
+// VerifyReader checks the signature of everything read from the reader, see Verify
+func VerifyReader(key *rsa.PublicKey, r io.Reader, signature []byte, opts SignatureOptions) error {
+	opts, err := opts.withDefaults()
+	if err != nil {
+		return err
+	}
+	digest, err := digestReader(r, opts.Hash)
+	if err != nil {
+		return err
+	}
+	return verifyDigest(key, digest, signature, opts)
+}
+
+// SignFile signs the file and writes the raw signature to a detached signature file, which can be
+// checked with openssl dgst -verify
+func SignFile(key *rsa.PrivateKey, path string, signaturePath string, opts SignatureOptions) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	signature, err := SignReader(key, f, opts)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(signaturePath, signature, signatureFileMode)
+}
+
+// VerifyFile checks the file against a detached signature file, see Verify
+func VerifyFile(key *rsa.PublicKey, path string, signaturePath string, opts SignatureOptions) error {
+	signature, err := os.ReadFile(signaturePath)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return VerifyReader(key, f, signature, opts)
+}
+
+func (opts SignatureOptions) withDefaults() (SignatureOptions, error) {
+	if opts.Scheme == 0 {
+		opts.Scheme = PSS
+	}
+	if opts.Hash == 0 {
+		opts.Hash = crypto.SHA256
+	}
+
+	if opts.Scheme != PSS && opts.Scheme != PKCS1v15 {
+		return opts, fmt.Errorf("unknown signature scheme %d", opts.Scheme)
+	}
+	if opts.Hash != crypto.SHA256 && opts.Hash != crypto.SHA384 && opts.Hash != crypto.SHA512 {
+		return opts, errors.New("signature hash must be either SHA-256, SHA-384 or SHA-512")
+	}
+	return opts, nil
+}
+
+func digestReader(r io.Reader, hash crypto.Hash) ([]byte, error) {
+	h := hash.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+func signDigest(key *rsa.PrivateKey, digest []byte, opts SignatureOptions) ([]byte, error) {
+	if opts.Scheme == PKCS1v15 {
+		return rsa.SignPKCS1v15(rand.Reader, key, opts.Hash, digest)
+	}
+	return rsa.SignPSS(rand.Reader, key, opts.Hash, digest, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+}
+
+func verifyDigest(key *rsa.PublicKey, digest []byte, signature []byte, opts SignatureOptions) error {
+	var err error
+	if opts.Scheme == PKCS1v15 {
+		err = rsa.VerifyPKCS1v15(key, opts.Hash, digest, signature)
+	} else {
+		err = rsa.VerifyPSS(key, opts.Hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto})
+	}
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package rsa
+
+import (
+	"bytes"
+	"crypto"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifyOpenSSLSignatures(t *testing.T) {
+	pub, _ := LoadPublicKey("testdata/public.pem")
+
+	// Written by openssl dgst -sign, the PSS signature with the maximum salt length
+	for _, tc := range []struct {
+		path string
+		opts SignatureOptions
+	}{
+		{"testdata/message_pkcs1v15_sha256.sig", SignatureOptions{Scheme: PKCS1v15, Hash: crypto.SHA256}},
+		{"testdata/message_pss_sha384.sig", SignatureOptions{Scheme: PSS, Hash: crypto.SHA384}},
+	} {
+		if err := VerifyFile(pub, "testdata/message.txt", tc.path, tc.opts); err != nil {
+			t.Errorf("Expected %s to verify but got %v", tc.path, err)
+		}
+		if err := VerifyFile(pub, "testdata/public.pem", tc.path, tc.opts); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("Expected ErrInvalidSignature for another file with %s but got %v", tc.path, err)
+		}
+	}
+
+	// The scheme and the hash must match the signature
+	err := VerifyFile(pub, "testdata/message.txt", "testdata/message_pss_sha384.sig", SignatureOptions{Scheme: PSS, Hash: crypto.SHA256})
+	if !errors.Is(err, ErrInvalidSignature) {
+		t.Fatal("Expected ErrInvalidSignature for another hash but got", err)
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	priv, _ := LoadPrivateKey("testdata/private.pem")
+	message := []byte("webhook payload")
+
+	for _, scheme := range []SignatureScheme{PSS, PKCS1v15} {
+		for _, hash := range []crypto.Hash{crypto.SHA256, crypto.SHA384, crypto.SHA512} {
+			opts := SignatureOptions{Scheme: scheme, Hash: hash}
+			signature, err := Sign(priv, message, opts)
+			if err != nil {
+				t.Fatalf("Did not expect an error for scheme %d and %s but got %q", scheme, hash, err)
+			}
+			if err := Verify(&priv.PublicKey, message, signature, opts); err != nil {
+				t.Errorf("Expected the signature of scheme %d and %s to verify (%v)", scheme, hash, err)
+			}
+			if err := VerifyReader(&priv.PublicKey, bytes.NewReader(message), signature, opts); err != nil {
+				t.Errorf("Expected the streamed signature of scheme %d and %s to verify (%v)", scheme, hash, err)
+			}
+
+			tampered := append([]byte(nil), signature...)
+			tampered[0] ^= 1
+			if err := Verify(&priv.PublicKey, message, tampered, opts); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("Expected ErrInvalidSignature for scheme %d and %s but got %v", scheme, hash, err)
+			}
+		}
+	}
+
+	if _, err := Sign(priv, message, SignatureOptions{Hash: crypto.SHA1}); err == nil {
+		t.Fatal("Expected an error for SHA-1")
+	}
+}
+
+func TestSignFile(t *testing.T) {
+	priv, _ := LoadPrivateKey("testdata/private.pem")
+	dir := t.TempDir()
+	path := filepath.Join(dir, "settlement.csv")
+	signaturePath := path + ".sig"
+	_ = os.WriteFile(path, bytes.Repeat([]byte("0123456789,"), 100000), 0644)
+
+	if err := SignFile(priv, path, signaturePath, SignatureOptions{}); err != nil {
+		t.Fatal("Did not expect an error", err)
+	}
+	if err := VerifyFile(&priv.PublicKey, path, signaturePath, SignatureOptions{}); err != nil {
+		t.Fatal("Expected the detached signature to verify", err)
+	}
+
+	_ = os.WriteFile(path, []byte("tampered"), 0644)
+	if err := VerifyFile(&priv.PublicKey, path, signaturePath, SignatureOptions{}); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatal("Expected ErrInvalidSignature for a modified file but got", err)
+	}
+}
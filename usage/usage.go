@@ -0,0 +1,119 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+
+// Package usage counts how many times a key has been used so that callers can rotate it before
+// reaching the invocation limits of its algorithm
+package usage
+
+import (
+	"errors"
+	"sync"
+)
+
+const (
+	// GCMRandomNonceInvocations is the NIST SP 800-38D limit of AES-GCM encryptions with random
+	// 96-bit nonces under the same key
+	GCMRandomNonceInvocations = 1 << 32
+	// TripleDESBlocks is the NIST SP 800-67 Rev 2 limit of 64-bit block encryptions under the
+	// same 3DES key bundle
+	TripleDESBlocks = 1 << 20
+)
+
+// ErrLimitExceeded is returned when the key has reached its hard usage limit and must be rotated
+var ErrLimitExceeded = errors.New("key usage limit exceeded")
+
+// Store persists the usage counters, Add must be atomic across every process sharing the key
+type Store interface {
+	// Add increments the counter of the key by n and returns the new total
+	Add(keyID string, n uint64) (uint64, error)
+}
+
+// MemoryStore is an in memory Store, suitable for tests and for keys that never outlive the process
+type MemoryStore struct {
+	mu       sync.Mutex
+	counters map[string]uint64
+}
+
+// NewMemoryStore constructs an empty in memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{counters: make(map[string]uint64)}
+}
+
+// Add increments the counter of the key by n and returns the new total
+func (s *MemoryStore) Add(keyID string, n uint64) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.counters[keyID] += n
+	return s.counters[keyID], nil
+}
+
+// Limits are the usage thresholds of a key, a zero limit is disabled
+type Limits struct {
+	// SoftLimit is the usage count at which OnSoftLimit is called, typically to trigger a rotation
+	SoftLimit uint64
+	// HardLimit is the usage count above which the key is refused
+	HardLimit uint64
+	// OnSoftLimit is called once, by the usage crossing the soft limit
+	OnSoftLimit func(keyID string, count uint64)
+}
+
+// GCMLimits returns the limits of an AES-GCM key with random nonces, the soft limit being half of
+// the hard limit
+func GCMLimits(onSoftLimit func(keyID string, count uint64)) Limits {
+	return Limits{
+		SoftLimit:   GCMRandomNonceInvocations / 2,
+		HardLimit:   GCMRandomNonceInvocations,
+		OnSoftLimit: onSoftLimit,
+	}
+}
+
+// TripleDESLimits returns the limits of a 3DES key, the soft limit being half of the hard limit
+func TripleDESLimits(onSoftLimit func(keyID string, count uint64)) Limits {
+	return Limits{
+		SoftLimit:   TripleDESBlocks / 2,
+		HardLimit:   TripleDESBlocks,
+		OnSoftLimit: onSoftLimit,
+	}
+}
+
+// Tracker accounts the usage of a single key against its limits
+type Tracker struct {
+	keyID  string
+	store  Store
+	limits Limits
+}
+
+// NewTracker constructs a tracker of the key identified by keyID, e.g. its check value
+func NewTracker(keyID string, store Store, limits Limits) *Tracker {
+	return &Tracker{keyID, store, limits}
+}
+
+// Use records n more usages of the key, ErrLimitExceeded is returned and the operation must not
+// be carried out if the total is above the hard limit
+func (t *Tracker) Use(n uint64) error {
+	count, err := t.store.Add(t.keyID, n)
+	if err != nil {
+		return err
+	}
+
+	if t.limits.HardLimit != 0 && count > t.limits.HardLimit {
+		return ErrLimitExceeded
+	}
+
+	// Only the usage crossing the soft limit triggers the callback, even across processes
+	soft := t.limits.SoftLimit
+	if soft != 0 && t.limits.OnSoftLimit != nil && count >= soft && count-n < soft {
+		t.limits.OnSoftLimit(t.keyID, count)
+	}
+	return nil
+}
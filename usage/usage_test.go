@@ -0,0 +1,76 @@
+/*
+	Licensed under the Apache License, Version 2.0 (the "License");
+	you may not use this file except in compliance with the License.
+	You may obtain a copy of the License at
+		http://www.apache.org/licenses/LICENSE-2.0
+	Unless required by applicable law or agreed to in writing, software
+	distributed under the License is distributed on an "AS IS" BASIS,
+	WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+	See the License for the specific language governing permissions and
+	limitations under the License.
+*/
+package usage
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTracker_Limits(t *testing.T) {
+	var softCalls []uint64
+	limits := Limits{
+		SoftLimit: 3,
+		HardLimit: 5,
+		OnSoftLimit: func(keyID string, count uint64) {
+			if keyID != "key-1" {
+				t.Errorf("Expected key-1 but got %s", keyID)
+			}
+			softCalls = append(softCalls, count)
+		},
+	}
+	tracker := NewTracker("key-1", NewMemoryStore(), limits)
+
+	for i := 0; i < 5; i++ {
+		if err := tracker.Use(1); err != nil {
+			t.Errorf("Did not expect an error but got %q", err)
+		}
+	}
+	if len(softCalls) != 1 || softCalls[0] != 3 {
+		t.Errorf("Expected a single soft limit call at 3 but got %v", softCalls)
+	}
+
+	if err := tracker.Use(1); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("Expected a limit exceeded error but got %v", err)
+	}
+}
+
+func TestTracker_SoftLimitCrossedByBatch(t *testing.T) {
+	calls := 0
+	tracker := NewTracker("key-1", NewMemoryStore(), Limits{
+		SoftLimit:   10,
+		OnSoftLimit: func(string, uint64) { calls++ },
+	})
+
+	tracker.Use(8)
+	tracker.Use(8)
+	tracker.Use(8)
+	if calls != 1 {
+		t.Errorf("Expected a single soft limit call but got %d", calls)
+	}
+}
+
+func TestTracker_SharedStore(t *testing.T) {
+	store := NewMemoryStore()
+	first := NewTracker("key-1", store, Limits{HardLimit: 2})
+	second := NewTracker("key-1", store, Limits{HardLimit: 2})
+	other := NewTracker("key-2", store, Limits{HardLimit: 2})
+
+	first.Use(1)
+	second.Use(1)
+	if err := first.Use(1); !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("Expected a limit exceeded error but got %v", err)
+	}
+	if err := other.Use(2); err != nil {
+		t.Errorf("Did not expect an error but got %q", err)
+	}
+}